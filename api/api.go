@@ -4,6 +4,7 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -17,7 +18,14 @@ type MetricOpts struct {
 	MetricNamePrefix         string
 	PrefixSeparator          string
 	Descriptions             MetricDescriptions
-	CaseSensitiveMetricNames bool // true is faster, default is Insensitive
+	CaseSensitiveMetricNames bool           // true is faster, default is Insensitive
+	Backend                  Backend        // default is NewPrometheusBackend(Registry)
+	Catalogue                *Catalogue     // optional declared metrics, e.g. from LoadCatalogue
+	Strict                   bool           // only create metrics declared in Catalogue or Descriptions
+	ErrorPolicy              ErrorPolicy    // for undeclared metrics and type conflicts, default is PanicOnError
+	Naming                   NamingPolicy   // checks new names against the Prometheus conventions, default is unchecked
+	NameValidation           NameValidation // default is LegacyNames
+	Clock                    Clock          // for timers and SLO burn rates, default is the system clock
 }
 
 type PrometheusMetrics interface {
@@ -37,18 +45,31 @@ type PrometheusMetrics interface {
 	Summary(name string, optionalDesc ...string) SummaryFacade
 	SummaryWithLabel(name string, labelName string, optionalDesc ...string) LabelledSummaryFacade
 	SummaryWithLabels(name string, labelNames []string, optionalDesc ...string) LabelledSummaryFacade
-	Timer(Name string) func() time.Duration
-	TimerWithLabel(Name string, labelName string, labelValue string) func() time.Duration
+	Timer(Name string) *Stopwatch
+	TimerWithLabel(Name string, labelName string, labelValue string) *Stopwatch
+	TimerWithLabels(Name string, labelNames []string, labelValues ...string) *Stopwatch
+	Time(name string, fn func() error) error
+	SLO(name string, target float64, latencyThreshold time.Duration, windows ...time.Duration) *SLO
+	WithUnit(unit Unit) PrometheusMetrics
 }
 
 type PrometheusMetricsImpl struct {
 	registry         prometheus.Registerer
 	metricNamePrefix string
 	descriptions     MetricDescriptions
-	errorCounter     *prometheus.CounterVec
+	errorCounter     CounterVecInstrument
 	errorCounterName string
 	registrations    MetricRegistrations
-	timerFactory     timerFactory
+	clock            Clock
+	backend          Backend
+	definitions      map[string]MetricDefinition
+	strict           bool
+	errorPolicy      ErrorPolicy
+	naming           NamingPolicy
+	nameValidation   NameValidation
+
+	undeclaredCounter CounterVecInstrument
+	slos              sloRegistry
 
 	caseSensitiveMetricNames bool // true is faster, default is Insensitive
 	normalisedNames          normalisedNames
@@ -59,7 +80,7 @@ func NewMetrics(opts MetricOpts) PrometheusMetricsImpl {
 		opts.PrefixSeparator = "_" // as per Prometheus lib standard
 	}
 
-	prefix := NormaliseAndLowercaseName(opts.MetricNamePrefix)
+	prefix := normaliseName(opts.MetricNamePrefix, false, opts.NameValidation)
 	if prefix != "" && !strings.HasSuffix(prefix, opts.PrefixSeparator) {
 		prefix += opts.PrefixSeparator
 	}
@@ -68,11 +89,31 @@ func NewMetrics(opts MetricOpts) PrometheusMetricsImpl {
 		opts.Registry = prometheus.DefaultRegisterer
 	}
 
+	if opts.Clock == nil {
+		opts.Clock = systemClock{}
+	}
+
+	if opts.Backend == nil {
+		opts.Backend = NewPrometheusBackend(opts.Registry)
+	}
+
+	if recording, ok := opts.Backend.(*RecordingBackend); ok {
+		recording.useClock(opts.Clock)
+	}
+
+	definitions := opts.Catalogue.definitions(opts.CaseSensitiveMetricNames, opts.NameValidation)
+
 	return PrometheusMetricsImpl{registry: opts.Registry,
 		metricNamePrefix:         prefix,
-		descriptions:             opts.Descriptions,
+		descriptions:             opts.Catalogue.descriptions(definitions, opts.Descriptions),
+		definitions:              definitions,
+		strict:                   opts.Strict,
+		errorPolicy:              opts.ErrorPolicy,
+		naming:                   opts.Naming,
+		nameValidation:           opts.NameValidation,
 		registrations:            newMetricRegistrations(),
-		timerFactory:             &defaultTimerFactory{},
+		clock:                    opts.Clock,
+		backend:                  opts.Backend,
 		caseSensitiveMetricNames: opts.CaseSensitiveMetricNames,
 		normalisedNames:          normalisedNames{internal: make(map[string]string)},
 	}
@@ -91,15 +132,18 @@ const (
 type metricEntry struct {
 	metric     metricFacade
 	metricType int
+	labelNames []string
+	help       string
+	unit       string
 }
 
 type MetricRegistrations struct {
 	sync.RWMutex
-	internal map[string]metricEntry
+	internal map[string]*metricEntry // pointers, so lookups don't copy every field
 }
 
 func newMetricRegistrations() MetricRegistrations {
-	return MetricRegistrations{internal: make(map[string]metricEntry)}
+	return MetricRegistrations{internal: make(map[string]*metricEntry)}
 }
 
 type normalisedNames struct {
@@ -112,39 +156,79 @@ func newNormalisedNames() normalisedNames {
 }
 
 func (p *PrometheusMetricsImpl) Register(metric prometheus.Collector) error {
-	return p.registry.Register(metric)
+	return p.getRegistry().Register(metric)
 }
 
 func (p *PrometheusMetricsImpl) MustRegister(metric prometheus.Collector) {
-	p.registry.MustRegister(metric)
+	p.getRegistry().MustRegister(metric)
 }
 
 type MetricBuilder func(p *PrometheusMetricsImpl, name string, desc string) interface{}
 
-func (p *PrometheusMetricsImpl) getOrAdd(name string, metricType int, builder MetricBuilder, desc []string) metricFacade {
-	var metricKey string
+// getOrAdd builds while holding the registrations lock, so concurrent callers can't register the same metric
+// twice, and TestHelper.Clear can't swap the registry or backend mid-build. unit is the base unit the metric
+// observes, if known.
+func (p *PrometheusMetricsImpl) getOrAdd(name string, metricType int, unit string, labelNames []string, builder MetricBuilder, desc []string) metricFacade {
+	metricKey := p.getMetricKey(name)
+
+	entry, ok := p.getRegistration(metricKey)
+	if !ok {
+		var violation string
+		if metricKey, violation = p.applyNaming(name, metricKey, metricType, unit); violation != "" {
+			return p.handleError(violation, p.getFullMetricName(metricKey), builder)
+		}
+		entry, ok = p.getRegistration(metricKey)
+	}
 
-	if p.caseSensitiveMetricNames {
-		metricKey = normalizer.Replace(name)
-	} else {
-		if entry, ok := p.getNormalisedName(name); ok {
-			metricKey = entry
-		} else {
-			metricKey = NormaliseAndLowercaseName(name)
-			p.storeNormalisedName(name, metricKey)
+	if !ok && p.strict {
+		if violation := p.checkDeclared(metricKey, metricType, labelNames); violation != "" {
+			p.countUndeclared(p.getFullMetricName(metricKey))
+			return p.handleError(violation, p.getFullMetricName(metricKey), builder)
 		}
 	}
 
-	if entry, ok := p.getRegistration(metricKey); ok {
-		if entry.metricType != metricType {
-			panic(p.getFullMetricName(metricKey) + " is already used for a different type of metric")
+	if !ok {
+		p.registrations.Lock()
+		if entry, ok = p.registrations.internal[metricKey]; !ok {
+			help := p.bestDescription(metricKey, desc)
+			newMetric := builder(p, p.getFullMetricName(metricKey), help)
+			entry = &metricEntry{metric: newMetric, metricType: metricType, labelNames: labelNames, help: help, unit: unit}
+			p.registrations.internal[metricKey] = entry
 		}
-		return entry.metric
+		p.registrations.Unlock()
 	}
 
-	var newMetric = builder(p, p.getFullMetricName(metricKey), p.bestDescription(metricKey, desc))
-	p.storeRegistration(metricKey, metricEntry{metric: newMetric, metricType: metricType})
-	return newMetric
+	if entry.metricType != metricType {
+		return p.handleError(p.getFullMetricName(metricKey)+" is already used for a different type of metric", p.getFullMetricName(metricKey), builder)
+	}
+	return entry.metric
+}
+
+func (p *PrometheusMetricsImpl) getMetricKey(name string) string {
+	if p.caseSensitiveMetricNames && p.naming != NamingFix {
+		return p.normaliseName(name)
+	}
+
+	if entry, ok := p.getNormalisedName(name); ok {
+		return entry
+	}
+
+	if p.caseSensitiveMetricNames {
+		return p.normaliseName(name) // only fixed names are cached
+	}
+
+	metricKey := p.normaliseName(name)
+	p.storeNormalisedName(name, metricKey)
+	return metricKey
+}
+
+// normaliseName makes name valid under the name validation scheme, also when it starts the full name
+func (p *PrometheusMetricsImpl) normaliseName(name string) string {
+	metricKey := normaliseName(name, p.caseSensitiveMetricNames, p.nameValidation)
+	if p.nameValidation == LegacyNames && p.metricNamePrefix == "" && metricKey != "" && metricKey[0] >= '0' && metricKey[0] <= '9' {
+		return "_" + metricKey
+	}
+	return metricKey
 }
 
 func (p *PrometheusMetricsImpl) getNormalisedName(name string) (string, bool) {
@@ -159,17 +243,17 @@ func (p *PrometheusMetricsImpl) storeNormalisedName(name string, value string) {
 	p.normalisedNames.internal[name] = value
 }
 
-func (p *PrometheusMetricsImpl) getRegistration(key string) (metricEntry, bool) {
+func (p *PrometheusMetricsImpl) getRegistration(key string) (*metricEntry, bool) {
 	p.registrations.RLock()
 	defer p.registrations.RUnlock()
 	val, ok := p.registrations.internal[key]
 	return val, ok
 }
 
-func (p *PrometheusMetricsImpl) storeRegistration(key string, value metricEntry) {
-	p.registrations.Lock()
-	defer p.registrations.Unlock()
-	p.registrations.internal[key] = value
+func (p *PrometheusMetricsImpl) getRegistry() prometheus.Registerer {
+	p.registrations.RLock()
+	defer p.registrations.RUnlock()
+	return p.registry
 }
 
 func (p *PrometheusMetricsImpl) bestDescription(name string, desc []string) string {
@@ -192,11 +276,83 @@ func (p *PrometheusMetricsImpl) getFullMetricName(name string) string {
 	return p.metricNamePrefix + name
 }
 
-var normalizer = strings.NewReplacer(".", "_", "-", "_", "#", "_", " ", "_")
+// NameValidation says which names Prometheus is expected to accept
+type NameValidation int
 
+const (
+	LegacyNames NameValidation = iota // default, replacing every rune other than [a-zA-Z0-9_:] in names with _
+	UTF8Names                         // keeping names as given, e.g. http.requests, as Prometheus 3 accepts
+)
+
+// NormaliseAndLowercaseName returns name as a valid legacy metric name, in lower case
 func NormaliseAndLowercaseName(name string) string {
-	if name == "" {
-		return ""
+	return normaliseName(name, false, LegacyNames)
+}
+
+func normaliseName(name string, caseSensitive bool, validation NameValidation) string {
+	if validation == UTF8Names {
+		if !utf8.ValidString(name) {
+			name = strings.ToValidUTF8(name, "_")
+		}
+	} else if !isLegacyName(name) {
+		name = strings.Map(legacyRune(true), name)
+	}
+
+	if caseSensitive {
+		return name
+	}
+	return strings.ToLower(name)
+}
+
+// normaliseLabelNames returns valid label names, only copying labelNames if any need to change
+func (p *PrometheusMetricsImpl) normaliseLabelNames(labelNames []string) []string {
+	var normalised []string
+	for i, each := range labelNames {
+		var label string
+		if p.nameValidation == UTF8Names {
+			label = strings.ToValidUTF8(each, "_")
+		} else if label = strings.Map(legacyRune(false), each); label != "" && label[0] >= '0' && label[0] <= '9' {
+			label = "_" + label
+		}
+
+		if label != each && normalised == nil {
+			normalised = append([]string(nil), labelNames...)
+		}
+		if normalised != nil {
+			normalised[i] = label
+		}
+	}
+
+	if normalised == nil {
+		return labelNames
+	}
+	return normalised
+}
+
+// legacyBytes are those allowed in legacy names, as a table for isLegacyName
+var legacyBytes = func() (table [256]bool) {
+	for b := range table {
+		table[b] = legacyRune(true)(rune(b)) == rune(b) && b < 0x80
+	}
+	return table
+}()
+
+// isLegacyName checks name is already valid, so most need no mapping
+func isLegacyName(name string) bool {
+	for i := 0; i < len(name); i++ {
+		if !legacyBytes[name[i]] {
+			return false
+		}
+	}
+	return true
+}
+
+// legacyRune replaces runes not allowed in legacy names with _. Only metric names may contain colons.
+func legacyRune(colons bool) func(rune) rune {
+	return func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || (colons && r == ':') {
+			return r
+		}
+		return '_'
 	}
-	return strings.ToLower(normalizer.Replace(name))
 }
@@ -2,6 +2,7 @@ package api
 
 import (
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -52,6 +53,46 @@ func BenchmarkTimer(b *testing.B) {
 	}
 }
 
+func BenchmarkSLORecord(b *testing.B) {
+	slo := caseInsensitiveMetrics.SLO("bench", 0.99, time.Second)
+	for n := 0; n < b.N; n++ {
+		slo.Record(time.Millisecond, nil)
+	}
+}
+
 func anotherTimedMethod() {
-	defer caseInsensitiveMetrics.Timer("T")()
+	defer caseInsensitiveMetrics.Timer("T").Stop()
+}
+
+var noopMetricsImpl = NewNoopMetrics()
+
+func BenchmarkNoopCounter(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		noopMetricsImpl.Counter("ANonReuseS").Inc()
+	}
+}
+
+func BenchmarkNoopLabelledCounter(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		noopMetricsImpl.CounterWithLabels("Labelled", []string{"country"}).IncLabel("uk")
+	}
+}
+
+func BenchmarkNoopLabelledCounterReuse(b *testing.B) {
+	b.ReportAllocs()
+	x := noopMetricsImpl.CounterWithLabels("Labelled", []string{"country"})
+	for n := 0; n < b.N; n++ {
+		x.IncLabel("uk")
+	}
+}
+
+func BenchmarkNoopTimer(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		func() {
+			defer noopMetricsImpl.Timer("T").Stop()
+		}()
+	}
 }
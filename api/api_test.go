@@ -25,7 +25,8 @@ func doTestCounter(t *testing.T, metrics *PrometheusMetricsImpl) {
 
 	m := findMetric("z_mine", metrics.gatherOK(t))
 	assert.Equal(t, 1, len(m.Metric))
-	assert.Equal(t, "name:\"z_mine\" help:\"z_mine\" type:COUNTER metric:<counter:<value:9 > >", strings.TrimSpace(m.String()))
+	assertFamily(t, m, "z_mine", clientmodel.MetricType_COUNTER)
+	assert.Equal(t, 9.0, m.Metric[0].GetCounter().GetValue())
 }
 
 func TestCounterWithExplicitDescription(t *testing.T) {
@@ -34,7 +35,7 @@ func TestCounterWithExplicitDescription(t *testing.T) {
 	metrics.Counter("123", "MyDesc").Inc()
 
 	m := findMetric("blah_123", metrics.gatherOK(t))
-	assert.Contains(t, m.String(), "name:\"blah_123\" help:\"MyDesc\" type:COUNTER")
+	assertFamily(t, m, "MyDesc", clientmodel.MetricType_COUNTER)
 }
 
 func TestCounterWithMappedDescription(t *testing.T) {
@@ -47,9 +48,9 @@ func TestCounterWithMappedDescription(t *testing.T) {
 	metrics.Counter("mapped_blank").Inc()
 
 	gathered := metrics.gatherOK(t)
-	assert.Contains(t, findMetric("a_mapped", gathered).String(), "name:\"a_mapped\" help:\"Description found\" type:COUNTER")
-	assert.Contains(t, findMetric("a_unmapped", gathered).String(), "name:\"a_unmapped\" help:\"a_unmapped\" type:COUNTER")
-	assert.Contains(t, findMetric("a_mapped_blank", gathered).String(), "name:\"a_mapped_blank\" help:\"\" type:COUNTER")
+	assertFamily(t, findMetric("a_mapped", gathered), "Description found", clientmodel.MetricType_COUNTER)
+	assertFamily(t, findMetric("a_unmapped", gathered), "a_unmapped", clientmodel.MetricType_COUNTER)
+	assertFamily(t, findMetric("a_mapped_blank", gathered), "", clientmodel.MetricType_COUNTER)
 }
 
 func TestCounterCaseInsensitivity(t *testing.T) {
@@ -60,7 +61,8 @@ func TestCounterCaseInsensitivity(t *testing.T) {
 	metrics.Counter("ABCD").Inc()
 
 	m := findMetric("blah_abcd", metrics.gatherOK(t))
-	assert.Equal(t, "name:\"blah_abcd\" help:\"blah_abcd\" type:COUNTER metric:<counter:<value:3 > >", strings.TrimSpace(m.String()))
+	assertFamily(t, m, "blah_abcd", clientmodel.MetricType_COUNTER)
+	assert.Equal(t, 3.0, m.Metric[0].GetCounter().GetValue())
 }
 
 func TestCounterCaseSensitivity(t *testing.T) {
@@ -71,9 +73,11 @@ func TestCounterCaseSensitivity(t *testing.T) {
 	metrics.Counter("ABCD").Inc()
 
 	gathered := metrics.gatherOK(t)
-	assert.Equal(t, "name:\"blah_abcd\" help:\"blah_abcd\" type:COUNTER metric:<counter:<value:1 > >", strings.TrimSpace(findMetric("blah_abcd", gathered).String()))
-	assert.Equal(t, "name:\"blah_AbCd\" help:\"blah_AbCd\" type:COUNTER metric:<counter:<value:1 > >", strings.TrimSpace(findMetric("blah_AbCd", gathered).String()))
-	assert.Equal(t, "name:\"blah_ABCD\" help:\"blah_ABCD\" type:COUNTER metric:<counter:<value:1 > >", strings.TrimSpace(findMetric("blah_ABCD", gathered).String()))
+	for _, name := range []string{"blah_abcd", "blah_AbCd", "blah_ABCD"} {
+		m := findMetric(name, gathered)
+		assertFamily(t, m, name, clientmodel.MetricType_COUNTER)
+		assert.Equal(t, 1.0, m.Metric[0].GetCounter().GetValue())
+	}
 }
 
 func TestCounterWithAllDefaultOptions(t *testing.T) {
@@ -82,7 +86,8 @@ func TestCounterWithAllDefaultOptions(t *testing.T) {
 	metrics.Counter("abcde").Inc()
 
 	m := findMetric("abcde", metrics.gatherOK(t))
-	assert.Equal(t, "name:\"abcde\" help:\"abcde\" type:COUNTER metric:<counter:<value:1 > >", strings.TrimSpace(m.String()))
+	assertFamily(t, m, "abcde", clientmodel.MetricType_COUNTER)
+	assert.Equal(t, 1.0, m.Metric[0].GetCounter().GetValue())
 }
 
 func TestCounterWithBlankExplicitName(t *testing.T) {
@@ -91,7 +96,8 @@ func TestCounterWithBlankExplicitName(t *testing.T) {
 	metrics.Counter("234", "").Inc()
 
 	m := findMetric("blah_234", metrics.gatherOK(t))
-	assert.Equal(t, "name:\"blah_234\" help:\"blah_234\" type:COUNTER metric:<counter:<value:1 > >", strings.TrimSpace(m.String()))
+	assertFamily(t, m, "blah_234", clientmodel.MetricType_COUNTER)
+	assert.Equal(t, 1.0, m.Metric[0].GetCounter().GetValue())
 }
 
 func TestCounterWithLabel(t *testing.T) {
@@ -124,7 +130,15 @@ func TestCounterWithLabels(t *testing.T) {
 	c.IncLabelBy("cat", "black").Value(3)
 
 	m := findMetric("v:animals", metrics.gatherOK(t))
-	assert.Equal(t, "name:\"v:animals\" help:\"desc\" type:COUNTER metric:<label:<name:\"animal\" value:\"cat\" > label:<name:\"breed\" value:\"black\" > counter:<value:4 > > metric:<label:<name:\"animal\" value:\"cat\" > label:<name:\"breed\" value:\"persian\" > counter:<value:1 > > metric:<label:<name:\"animal\" value:\"dog\" > label:<name:\"breed\" value:\"greyhound\" > counter:<value:1 > > metric:<label:<name:\"animal\" value:\"dog\" > label:<name:\"breed\" value:\"spaniel\" > counter:<value:16 > >", strings.TrimSpace(m.String()))
+	assertFamily(t, m, "desc", clientmodel.MetricType_COUNTER)
+	assert.Equal(t, 4, len(m.Metric))
+	for i, expected := range []struct {
+		animal, breed string
+		value         float64
+	}{{"cat", "black", 4}, {"cat", "persian", 1}, {"dog", "greyhound", 1}, {"dog", "spaniel", 16}} {
+		assert.Equal(t, map[string]string{"animal": expected.animal, "breed": expected.breed}, labelsOf(m.Metric[i].GetLabel()))
+		assert.Equal(t, expected.value, m.Metric[i].GetCounter().GetValue())
+	}
 }
 
 func TestBadNameReuse(t *testing.T) {
@@ -146,7 +160,8 @@ func TestGauge(t *testing.T) {
 
 	m := findMetric("x_service_123_mygauge", metrics.gatherOK(t))
 	assert.Equal(t, 1, len(m.Metric))
-	assert.Equal(t, "name:\"x_service_123_mygauge\" help:\"x_service_123_mygauge\" type:GAUGE metric:<gauge:<value:102 > >", strings.TrimSpace(m.String()))
+	assertFamily(t, m, "x_service_123_mygauge", clientmodel.MetricType_GAUGE)
+	assert.Equal(t, 102.0, m.Metric[0].GetGauge().GetValue())
 }
 
 func TestGaugeWithLabel(t *testing.T) {
@@ -161,7 +176,14 @@ func TestGaugeWithLabel(t *testing.T) {
 
 	m := findMetric("prefix_current_animals", metrics.gatherOK(t))
 	assert.Equal(t, 3, len(m.Metric))
-	assert.Equal(t, "name:\"prefix_current_animals\" help:\"prefix_current_animals\" type:GAUGE metric:<label:<name:\"animal\" value:\"cat\" > gauge:<value:4 > > metric:<label:<name:\"animal\" value:\"dog\" > gauge:<value:1 > > metric:<label:<name:\"animal\" value:\"fleas\" > gauge:<value:985 > >", strings.TrimSpace(m.String()))
+	assertFamily(t, m, "prefix_current_animals", clientmodel.MetricType_GAUGE)
+	for i, expected := range []struct {
+		animal string
+		value  float64
+	}{{"cat", 4}, {"dog", 1}, {"fleas", 985}} {
+		assert.Equal(t, map[string]string{"animal": expected.animal}, labelsOf(m.Metric[i].GetLabel()))
+		assert.Equal(t, expected.value, m.Metric[i].GetGauge().GetValue())
+	}
 }
 
 func TestGaugeWithLabels(t *testing.T) {
@@ -179,16 +201,13 @@ func TestGaugeWithLabels(t *testing.T) {
 
 	m := findMetric("prefix_current_animals", metrics.gatherOK(t))
 	assert.Equal(t, 5, len(m.Metric))
-	assert.Equal(t, "label:<name:\"animal\" value:\"cat\" > label:<name:\"breed\" value:\"black\" > gauge:<value:4 >",
-		strings.TrimSpace(m.Metric[0].String()))
-	assert.Equal(t, "label:<name:\"animal\" value:\"cat\" > label:<name:\"breed\" value:\"white\" > gauge:<value:0 >",
-		strings.TrimSpace(m.Metric[1].String()))
-	assert.Equal(t, "label:<name:\"animal\" value:\"dog\" > label:<name:\"breed\" value:\"borzoi\" > gauge:<value:1 >",
-		strings.TrimSpace(m.Metric[2].String()))
-	assert.Equal(t, "label:<name:\"animal\" value:\"fleas\" > label:<name:\"breed\" value:\"asian\" > gauge:<value:1000 >",
-		strings.TrimSpace(m.Metric[3].String()))
-	assert.Equal(t, "label:<name:\"animal\" value:\"fleas\" > label:<name:\"breed\" value:\"plague\" > gauge:<value:485 >",
-		strings.TrimSpace(m.Metric[4].String()))
+	for i, expected := range []struct {
+		animal, breed string
+		value         float64
+	}{{"cat", "black", 4}, {"cat", "white", 0}, {"dog", "borzoi", 1}, {"fleas", "asian", 1000}, {"fleas", "plague", 485}} {
+		assert.Equal(t, map[string]string{"animal": expected.animal, "breed": expected.breed}, labelsOf(m.Metric[i].GetLabel()))
+		assert.Equal(t, expected.value, m.Metric[i].GetGauge().GetValue())
+	}
 }
 
 func TestSummary(t *testing.T) {
@@ -205,8 +224,7 @@ func TestSummary(t *testing.T) {
 
 	m := findMetric("blah_mysummary", metrics.gatherOK(t))
 	assert.Equal(t, 1, len(m.Metric))
-	assert.Equal(t, "summary:<sample_count:7 sample_sum:19.634344000000002 quantile:<quantile:0.5 value:2.9 > quantile:<quantile:0.75 value:3.3 > quantile:<quantile:0.9 value:3.834344 > quantile:<quantile:0.95 value:3.834344 > quantile:<quantile:0.99 value:3.834344 > quantile:<quantile:0.999 value:3.834344 > >",
-		strings.TrimSpace(m.Metric[0].String()))
+	assertSummary(t, m.Metric[0], 7, 19.634344000000002, map[float64]float64{0.5: 2.9, 0.75: 3.3, 0.9: 3.834344, 0.95: 3.834344, 0.99: 3.834344, 0.999: 3.834344})
 }
 
 func TestSummaryWithLabel(t *testing.T) {
@@ -223,14 +241,14 @@ func TestSummaryWithLabel(t *testing.T) {
 
 	m := findMetric("blah_animal_facts", metrics.gatherOK(t))
 	assert.Equal(t, 4, len(m.Metric))
-	assert.Equal(t, "label:<name:\"animal\" value:\"ant\" > summary:<sample_count:1 sample_sum:3.2 quantile:<quantile:0.5 value:3.2 > quantile:<quantile:0.75 value:3.2 > quantile:<quantile:0.9 value:3.2 > quantile:<quantile:0.95 value:3.2 > quantile:<quantile:0.99 value:3.2 > quantile:<quantile:0.999 value:3.2 > >",
-		strings.TrimSpace(m.Metric[0].String()))
-	assert.Equal(t, "label:<name:\"animal\" value:\"bear\" > summary:<sample_count:1 sample_sum:3.834344 quantile:<quantile:0.5 value:3.834344 > quantile:<quantile:0.75 value:3.834344 > quantile:<quantile:0.9 value:3.834344 > quantile:<quantile:0.95 value:3.834344 > quantile:<quantile:0.99 value:3.834344 > quantile:<quantile:0.999 value:3.834344 > >",
-		strings.TrimSpace(m.Metric[1].String()))
-	assert.Equal(t, "label:<name:\"animal\" value:\"cat\" > summary:<sample_count:3 sample_sum:5.5 quantile:<quantile:0.5 value:2 > quantile:<quantile:0.75 value:2.5 > quantile:<quantile:0.9 value:2.5 > quantile:<quantile:0.95 value:2.5 > quantile:<quantile:0.99 value:2.5 > quantile:<quantile:0.999 value:2.5 > >",
-		strings.TrimSpace(m.Metric[2].String()))
-	assert.Equal(t, "label:<name:\"animal\" value:\"dog\" > summary:<sample_count:2 sample_sum:5.9 quantile:<quantile:0.5 value:2.6 > quantile:<quantile:0.75 value:3.3 > quantile:<quantile:0.9 value:3.3 > quantile:<quantile:0.95 value:3.3 > quantile:<quantile:0.99 value:3.3 > quantile:<quantile:0.999 value:3.3 > >",
-		strings.TrimSpace(m.Metric[3].String()))
+	assert.Equal(t, map[string]string{"animal": "ant"}, labelsOf(m.Metric[0].GetLabel()))
+	assertSummary(t, m.Metric[0], 1, 3.2, sameQuantiles(3.2))
+	assert.Equal(t, map[string]string{"animal": "bear"}, labelsOf(m.Metric[1].GetLabel()))
+	assertSummary(t, m.Metric[1], 1, 3.834344, sameQuantiles(3.834344))
+	assert.Equal(t, map[string]string{"animal": "cat"}, labelsOf(m.Metric[2].GetLabel()))
+	assertSummary(t, m.Metric[2], 3, 5.5, map[float64]float64{0.5: 2, 0.75: 2.5, 0.9: 2.5, 0.95: 2.5, 0.99: 2.5, 0.999: 2.5})
+	assert.Equal(t, map[string]string{"animal": "dog"}, labelsOf(m.Metric[3].GetLabel()))
+	assertSummary(t, m.Metric[3], 2, 5.9, map[float64]float64{0.5: 2.6, 0.75: 3.3, 0.9: 3.3, 0.95: 3.3, 0.99: 3.3, 0.999: 3.3})
 }
 
 func TestSummaryWithLabels(t *testing.T) {
@@ -243,12 +261,13 @@ func TestSummaryWithLabels(t *testing.T) {
 
 	m := findMetric("blah_animal_breeds", metrics.gatherOK(t))
 	assert.Equal(t, 3, len(m.Metric))
-	assert.Equal(t, "label:<name:\"animal\" value:\"cat\" > label:<name:\"breed\" value:\"siamese\" > summary:<sample_count:1 sample_sum:2.5 quantile:<quantile:0.5 value:2.5 > quantile:<quantile:0.75 value:2.5 > quantile:<quantile:0.9 value:2.5 > quantile:<quantile:0.95 value:2.5 > quantile:<quantile:0.99 value:2.5 > quantile:<quantile:0.999 value:2.5 > >",
-		strings.TrimSpace(m.Metric[0].String()))
-	assert.Equal(t, "label:<name:\"animal\" value:\"cat\" > label:<name:\"breed\" value:\"tabby\" > summary:<sample_count:1 sample_sum:1 quantile:<quantile:0.5 value:1 > quantile:<quantile:0.75 value:1 > quantile:<quantile:0.9 value:1 > quantile:<quantile:0.95 value:1 > quantile:<quantile:0.99 value:1 > quantile:<quantile:0.999 value:1 > >",
-		strings.TrimSpace(m.Metric[1].String()))
-	assert.Equal(t, "label:<name:\"animal\" value:\"dog\" > label:<name:\"breed\" value:\"mutt\" > summary:<sample_count:1 sample_sum:2.6 quantile:<quantile:0.5 value:2.6 > quantile:<quantile:0.75 value:2.6 > quantile:<quantile:0.9 value:2.6 > quantile:<quantile:0.95 value:2.6 > quantile:<quantile:0.99 value:2.6 > quantile:<quantile:0.999 value:2.6 > >",
-		strings.TrimSpace(m.Metric[2].String()))
+	for i, expected := range []struct {
+		animal, breed string
+		value         float64
+	}{{"cat", "siamese", 2.5}, {"cat", "tabby", 1}, {"dog", "mutt", 2.6}} {
+		assert.Equal(t, map[string]string{"animal": expected.animal, "breed": expected.breed}, labelsOf(m.Metric[i].GetLabel()))
+		assertSummary(t, m.Metric[i], 1, expected.value, sameQuantiles(expected.value))
+	}
 }
 
 func TestRegisterUnderlyingMetric(t *testing.T) {
@@ -261,7 +280,7 @@ func TestRegisterUnderlyingMetric(t *testing.T) {
 
 	m := findMetric(metricName, metrics.gatherOK(t))
 	assert.Equal(t, 1, len(m.Metric))
-	assert.Equal(t, "counter:<value:71 >", strings.TrimSpace(m.Metric[0].String()))
+	assert.Equal(t, 71.0, m.Metric[0].GetCounter().GetValue())
 }
 
 func TestHistogramForResponseTime(t *testing.T) {
@@ -278,8 +297,10 @@ func TestHistogramForResponseTime(t *testing.T) {
 
 	m := findMetric("a_myhisto", metrics.gatherOK(t))
 	assert.Equal(t, 1, len(m.Metric))
-	assert.Equal(t, "histogram:<sample_count:7 sample_sum:19.634344000000002 bucket:<cumulative_count:0 upper_bound:0.005 > bucket:<cumulative_count:0 upper_bound:0.01 > bucket:<cumulative_count:0 upper_bound:0.025 > bucket:<cumulative_count:0 upper_bound:0.05 > bucket:<cumulative_count:0 upper_bound:0.1 > bucket:<cumulative_count:0 upper_bound:0.25 > bucket:<cumulative_count:0 upper_bound:0.5 > bucket:<cumulative_count:0 upper_bound:1 > bucket:<cumulative_count:2 upper_bound:2.5 > bucket:<cumulative_count:7 upper_bound:5 > bucket:<cumulative_count:7 upper_bound:10 > >",
-		strings.TrimSpace(m.Metric[0].String()))
+	assert.Equal(t, uint64(7), m.Metric[0].GetHistogram().GetSampleCount())
+	assert.Equal(t, 19.634344000000002, m.Metric[0].GetHistogram().GetSampleSum())
+	assert.Equal(t, map[float64]uint64{0.005: 0, 0.01: 0, 0.025: 0, 0.05: 0, 0.1: 0, 0.25: 0, 0.5: 0, 1: 0, 2.5: 2, 5: 7, 10: 7},
+		bucketsOf(m.Metric[0].GetHistogram()))
 }
 
 func TestHistogramCustomBuckets(t *testing.T) {
@@ -296,8 +317,9 @@ func TestHistogramCustomBuckets(t *testing.T) {
 
 	m := findMetric("a_myhisto", metrics.gatherOK(t))
 	assert.Equal(t, 1, len(m.Metric))
-	assert.Equal(t, "histogram:<sample_count:7 sample_sum:19.634344000000002 bucket:<cumulative_count:1 upper_bound:2 > bucket:<cumulative_count:4 upper_bound:3 > bucket:<cumulative_count:6 upper_bound:3.5 > >",
-		strings.TrimSpace(m.Metric[0].String()))
+	assert.Equal(t, uint64(7), m.Metric[0].GetHistogram().GetSampleCount())
+	assert.Equal(t, 19.634344000000002, m.Metric[0].GetHistogram().GetSampleSum())
+	assert.Equal(t, map[float64]uint64{2: 1, 3: 4, 3.5: 6}, bucketsOf(m.Metric[0].GetHistogram()))
 }
 
 func TestErrors(t *testing.T) {
@@ -314,9 +336,13 @@ func doTestErrors(t *testing.T, metrics *PrometheusMetricsImpl) {
 
 	m := findMetric("z_errors", metrics.gatherOK(t))
 	assert.Equal(t, 3, len(m.Metric))
-	assert.Equal(t, "label:<name:\"error_type\" value:\"bad\" > counter:<value:1 >", strings.TrimSpace(m.Metric[0].String()))
-	assert.Equal(t, "label:<name:\"error_type\" value:\"generic\" > counter:<value:2 >", strings.TrimSpace(m.Metric[1].String()))
-	assert.Equal(t, "label:<name:\"error_type\" value:\"worse\" > counter:<value:1 >", strings.TrimSpace(m.Metric[2].String()))
+	for i, expected := range []struct {
+		errorType string
+		value     float64
+	}{{"bad", 1}, {"generic", 2}, {"worse", 1}} {
+		assert.Equal(t, map[string]string{"error_type": expected.errorType}, labelsOf(m.Metric[i].GetLabel()))
+		assert.Equal(t, expected.value, m.Metric[i].GetCounter().GetValue())
+	}
 }
 
 func TestClear(t *testing.T) {
@@ -340,26 +366,30 @@ func TestNames(t *testing.T) {
 }
 
 func TestTimersControlled(t *testing.T) {
-	metrics := PrometheusMetricsImpl{registry: prometheus.NewRegistry(),
+	registry := prometheus.NewRegistry()
+	clock := NewFakeClock(testStart)
+	metrics := PrometheusMetricsImpl{registry: registry,
 		metricNamePrefix: "xx_",
 		registrations:    newMetricRegistrations(),
 		normalisedNames:  newNormalisedNames(),
-		timerFactory:     &controlledTimerFactory{defaultExpectation: 2 * time.Second}}
+		clock:            clock,
+		backend:          NewPrometheusBackend(registry)}
 
-	timedMethod(&metrics)
-	timedMethod(&metrics)
+	timedFor(clock, 2*time.Second, metrics.Timer("Timer"))
+	timedFor(clock, 2*time.Second, metrics.Timer("Timer"))
 
 	m := findMetric("xx_timer", metrics.gatherOK(t))
 	assert.Equal(t, 1, len(m.Metric))
-	assert.Equal(t, "summary:<sample_count:2 sample_sum:4 quantile:<quantile:0.5 value:2 > quantile:<quantile:0.75 value:2 > quantile:<quantile:0.9 value:2 > quantile:<quantile:0.95 value:2 > quantile:<quantile:0.99 value:2 > quantile:<quantile:0.999 value:2 > >",
-		strings.TrimSpace(m.Metric[0].String()))
+	assertSummary(t, m.Metric[0], 2, 4, sameQuantiles(2))
 }
 
 func TestTimersRealTime(t *testing.T) {
-	metrics := PrometheusMetricsImpl{registry: prometheus.NewRegistry(),
+	registry := prometheus.NewRegistry()
+	metrics := PrometheusMetricsImpl{registry: registry,
 		registrations:   newMetricRegistrations(),
 		normalisedNames: newNormalisedNames(),
-		timerFactory:    &defaultTimerFactory{}}
+		clock:           systemClock{},
+		backend:         NewPrometheusBackend(registry)}
 
 	timedMethod(&metrics)
 
@@ -369,28 +399,218 @@ func TestTimersRealTime(t *testing.T) {
 }
 
 func TestLabelledTimersControlled(t *testing.T) {
-	metrics := PrometheusMetricsImpl{registry: prometheus.NewRegistry(),
+	registry := prometheus.NewRegistry()
+	clock := NewFakeClock(testStart)
+	metrics := PrometheusMetricsImpl{registry: registry,
 		metricNamePrefix: "xx_",
 		registrations:    newMetricRegistrations(),
 		normalisedNames:  newNormalisedNames(),
-		timerFactory:     &controlledTimerFactory{defaultExpectation: 2 * time.Second}}
+		clock:            clock,
+		backend:          NewPrometheusBackend(registry)}
 
-	timedMethodWithLabel(&metrics)
-	timedMethodWithLabel(&metrics)
+	timedFor(clock, 2*time.Second, metrics.TimerWithLabel("animal_timer", "animal", "cat"))
+	timedFor(clock, 2*time.Second, metrics.TimerWithLabel("animal_timer", "animal", "cat"))
 
 	m := findMetric("xx_animal_timer", metrics.gatherOK(t))
 	assert.Equal(t, 1, len(m.Metric))
-	assert.Equal(t, "label:<name:\"animal\" value:\"cat\" > summary:<sample_count:2 sample_sum:4 quantile:<quantile:0.5 value:2 > quantile:<quantile:0.75 value:2 > quantile:<quantile:0.9 value:2 > quantile:<quantile:0.95 value:2 > quantile:<quantile:0.99 value:2 > quantile:<quantile:0.999 value:2 > >",
-		strings.TrimSpace(m.Metric[0].String()))
+	assert.Equal(t, map[string]string{"animal": "cat"}, labelsOf(m.Metric[0].GetLabel()))
+	assertSummary(t, m.Metric[0], 2, 4, sameQuantiles(2))
+}
+
+func TestTypedValues(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "v", Clock: clock})
+	helper := metrics.TestHelper()
+
+	metrics.Counter("My.Counter").IncBy(3)
+	metrics.CounterWithLabels("animals", []string{"animal", "breed"}).IncLabelBy("cat", "persian").Value(16)
+	metrics.GaugeWithLabel("current animals", "animal").SetLabels("fleas").Value(1000)
+	metrics.Histogram("ages", []float64{18, 65}).Update(21)
+	metrics.Error("bad")
+	timedFor(clock, 2*time.Second, metrics.TimerWithLabel("animal_timer", "animal", "cat"))
+
+	value, err := helper.CounterValue("my.counter")
+	assert.Nil(t, err)
+	assert.Equal(t, 3.0, value)
+
+	value, err = helper.CounterValue("animals", "cat", "persian")
+	assert.Nil(t, err)
+	assert.Equal(t, 16.0, value)
+
+	value, err = helper.CounterValue("errors", "bad")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, value)
+
+	value, err = helper.GaugeValue("Current Animals", "fleas")
+	assert.Nil(t, err)
+	assert.Equal(t, 1000.0, value)
+
+	histogram, err := helper.HistogramSnapshot("ages")
+	assert.Nil(t, err)
+	assert.Equal(t, HistogramSnapshot{Count: 1, Sum: 21, Buckets: map[float64]uint64{18: 0, 65: 1}}, histogram)
+
+	summary, err := helper.SummarySnapshot("animal_timer", "cat")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), summary.Count)
+	assert.Equal(t, 2.0, summary.Quantiles[0.99])
+
+	_, err = helper.CounterValue("missing")
+	assert.EqualError(t, err, "metric v_missing not found")
+
+	_, err = helper.CounterValue("current animals", "fleas")
+	assert.EqualError(t, err, "metric v_current_animals is a GAUGE, not a COUNTER")
+
+	_, err = helper.CounterValue("animals", "cat")
+	assert.EqualError(t, err, "metric v_animals has labels [animal breed], but 1 label values were given")
+
+	_, err = helper.CounterValue("animals", "dog", "persian")
+	assert.EqualError(t, err, `metric v_animals has no series with labels {animal="dog", breed="persian"}`)
+}
+
+func TestNoopMetrics(t *testing.T) {
+	metrics := NewNoopMetrics()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		metrics.Counter("c").IncBy(2)
+		metrics.Gauge("g").SetValue(1)
+		metrics.HistogramForResponseTime("h").Update(0.1)
+		metrics.Summary("s").Observe(1)
+		metrics.Error("e")
+		timedMethod(metrics)
+	})
+	assert.Equal(t, 0.0, allocs)
+
+	animals := metrics.GaugeWithLabels("animals", []string{"type", "breed"})
+	labelledAllocs := testing.AllocsPerRun(100, func() {
+		metrics.CounterWithLabel("places", "city").IncLabelBy("London").Value(3)
+		metrics.CounterWithLabel("places", "city").IncLabelWithExemplar(Exemplar{TraceID: "t"}, "London")
+		animals.SetLabels("cat", "persian").Value(4)
+		animals.IncLabels("dog", "poodle")
+		metrics.SummaryWithLabel("populations", "city").Observe(8000000, "London")
+	})
+	assert.Equal(t, 0.0, labelledAllocs)
+
+	assert.Nil(t, metrics.Register(prometheus.NewCounter(prometheus.CounterOpts{Name: "unused", Help: "help"})))
+
+	assert.Empty(t, metrics.TestHelper().MetricNames())
+}
+
+func TestNoopBackend(t *testing.T) {
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "blah", Backend: NewNoopBackend()})
+	metrics.Counter("a").Inc()
+	timedMethodWithLabel(&metrics)
+
+	assert.Empty(t, metrics.TestHelper().MetricNames())
+	assert.Panics(t, func() { metrics.Gauge("a").Inc() }, "The code did not panic")
+}
+
+func TestFanout(t *testing.T) {
+	old := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "old"})
+	latest := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "new"})
+	metrics := NewFanoutMetrics(&old, &latest)
+
+	metrics.Counter("c").IncBy(2)
+	metrics.CounterWithLabel("places", "city").IncLabel("London")
+	metrics.GaugeWithLabels("animals", []string{"type", "breed"}).SetLabels("cat", "persian").Value(4)
+	metrics.Gauge("g").DecBy(3)
+	metrics.Histogram("h", []float64{1, 2}).Update(1.5)
+	metrics.SummaryWithLabel("populations", "city").Observe(8000000, "London")
+	metrics.Error("e")
+	timedMethod(metrics)
+	timedMethodWithLabel(metrics)
+
+	for _, each := range []*PrometheusMetricsImpl{&old, &latest} {
+		prefix := strings.TrimSuffix(each.metricNamePrefix, "_")
+		assert.ElementsMatch(t, []string{"c", "places", "animals", "g", "h", "populations", "errors", "timer", "animal_timer"},
+			withoutPrefix(prefix, each.TestHelper().MetricNames()))
+
+		gathered := each.gatherOK(t)
+		assert.Equal(t, 2.0, findMetric(prefix+"_c", gathered).Metric[0].GetCounter().GetValue())
+		assert.Equal(t, -3.0, findMetric(prefix+"_g", gathered).Metric[0].GetGauge().GetValue())
+		assert.Equal(t, uint64(1), findMetric(prefix+"_animal_timer", gathered).Metric[0].GetSummary().GetSampleCount())
+	}
+
+	assert.Equal(t, old.TestHelper().MetricNames(), metrics.TestHelper().MetricNames())
+}
+
+func TestFanoutConflicts(t *testing.T) {
+	old := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "old"})
+	latest := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "new"})
+	metrics := NewFanoutMetrics(&old, &latest)
+
+	metrics.Counter("a").Inc()
+	assert.PanicsWithValue(t, "a is already used for a different type of metric", func() { metrics.Gauge("A").Inc() })
+
+	// Neither child got as far as registering the gauge
+	assert.Equal(t, []string{"old_a"}, old.TestHelper().MetricNames())
+	assert.Equal(t, []string{"new_a"}, latest.TestHelper().MetricNames())
+}
+
+func TestFanoutWithNoop(t *testing.T) {
+	latest := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "new"})
+	metrics := NewFanoutMetrics(NewNoopMetrics(), &latest)
+
+	metrics.CounterWithLabel("places", "city").IncLabel("London")
+	metrics.GaugeWithLabel("animals", "type").SetLabels("cat").Value(4)
+	metrics.SummaryWithLabel("populations", "city").Observe(8000000, "London")
+
+	assert.ElementsMatch(t, []string{"new_places", "new_animals", "new_populations"}, latest.TestHelper().MetricNames())
+}
+
+func TestFanoutErrorPolicy(t *testing.T) {
+	old := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "old", ErrorPolicy: IgnoreOnError})
+	latest := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "new", ErrorPolicy: IgnoreOnError})
+	metrics := NewFanoutMetrics(&old, &latest)
+
+	metrics.Counter("a").Inc()
+	assert.NotPanics(t, func() { metrics.Gauge("a").Inc() })
+	assert.NotPanics(t, func() { metrics.WithUnit(Seconds).Timer("a").Stop() })
+}
+
+func TestFanoutMixedErrorPolicies(t *testing.T) {
+	old := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "old", ErrorPolicy: IgnoreOnError})
+	latest := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "new"})
+	metrics := NewFanoutMetrics(&old, &latest)
+
+	metrics.Counter("x").Inc()
+	assert.NotPanics(t, func() { metrics.Gauge("x").Inc() })
+	assert.NotPanics(t, func() { metrics.SummaryWithLabels("x", []string{"a"}).Observe(1, "b") })
+	assert.Nil(t, metrics.Timer("x"))
+
+	assert.Equal(t, []string{"old_x"}, old.TestHelper().MetricNames())
+	assert.Equal(t, []string{"new_x"}, latest.TestHelper().MetricNames())
+	assertValue(t, 1)(latest.TestHelper().CounterValue("x"))
+}
+
+func TestFanoutUnits(t *testing.T) {
+	old := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "old"})
+	latest := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "new"})
+	metrics := NewFanoutMetrics(&old, &latest)
+
+	metrics.Timer("x").Stop()
+	metrics.WithUnit(Seconds).Histogram("x", []float64{1}).Update(0.5)
+	metrics.Counter("size").Inc()
+	metrics.WithUnit(Bytes).Gauge("size").SetValue(10)
+
+	assert.ElementsMatch(t, []string{"old_x", "old_x_seconds", "old_size", "old_size_bytes"}, old.TestHelper().MetricNames())
+	assert.PanicsWithValue(t, "x_seconds is already used for a different type of metric", func() { metrics.WithUnit(Seconds).Timer("x") })
+}
+
+func withoutPrefix(prefix string, names []string) []string {
+	stripped := make([]string, len(names))
+	for i, each := range names {
+		stripped[i] = strings.TrimPrefix(each, prefix+"_")
+	}
+	return stripped
 }
 
 func timedMethod(metrics PrometheusMetrics) {
-	defer metrics.Timer("Timer")()
+	defer metrics.Timer("Timer").Stop()
 	fmt.Println("Whatever it is we're timing")
 }
 
 func timedMethodWithLabel(metrics PrometheusMetrics) {
-	defer metrics.TimerWithLabel("animal_timer", "animal", "cat")()
+	defer metrics.TimerWithLabel("animal_timer", "animal", "cat").Stop()
 	fmt.Println("Whatever it is we're timing")
 }
 
@@ -400,25 +620,54 @@ func (p *PrometheusMetricsImpl) gatherOK(t *testing.T) []*clientmodel.MetricFami
 	return gathered
 }
 
-type controlledTimer struct {
-	eventTimer
-	observer    prometheus.Observer
-	expectation time.Duration
+var testStart = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// timedFor stops the stopwatch once the clock has moved on by d
+func timedFor(clock *FakeClock, d time.Duration, stopwatch *Stopwatch) time.Duration {
+	clock.Advance(d)
+	return stopwatch.Stop()
 }
 
-type controlledTimerFactory struct {
-	timerFactory
-	defaultExpectation time.Duration
+// assertFamily checks a gathered family's help and type
+func assertFamily(t *testing.T, family *clientmodel.MetricFamily, help string, metricType clientmodel.MetricType) {
+	t.Helper()
+	assert.Equal(t, help, family.GetHelp())
+	assert.Equal(t, metricType, family.GetType())
 }
 
-func (f *controlledTimerFactory) NewTimer(o prometheus.Observer) eventTimer {
-	return &controlledTimer{observer: o, expectation: f.defaultExpectation}
+// assertSummary checks a gathered summary's count, sum and values by quantile
+func assertSummary(t *testing.T, metric *clientmodel.Metric, count uint64, sum float64, quantiles map[float64]float64) {
+	t.Helper()
+	assert.Equal(t, count, metric.GetSummary().GetSampleCount())
+	assert.Equal(t, sum, metric.GetSummary().GetSampleSum())
+
+	actual := map[float64]float64{}
+	for _, each := range metric.GetSummary().GetQuantile() {
+		actual[each.GetQuantile()] = each.GetValue()
+	}
+	assert.Equal(t, quantiles, actual)
+}
+
+// sameQuantiles is every default objective having the one value, as after a single observation
+func sameQuantiles(value float64) map[float64]float64 {
+	return map[float64]float64{0.5: value, 0.75: value, 0.9: value, 0.95: value, 0.99: value, 0.999: value}
 }
 
-func (t *controlledTimer) Observe() time.Duration {
-	fmt.Println("Making fixed observation...")
-	t.observer.Observe(t.expectation.Seconds())
-	return t.expectation
+func labelsOf(pairs []*clientmodel.LabelPair) map[string]string {
+	labels := make(map[string]string, len(pairs))
+	for _, each := range pairs {
+		labels[each.GetName()] = each.GetValue()
+	}
+	return labels
+}
+
+// bucketsOf returns a histogram's cumulative counts by upper bound
+func bucketsOf(histogram *clientmodel.Histogram) map[float64]uint64 {
+	buckets := map[float64]uint64{}
+	for _, each := range histogram.GetBucket() {
+		buckets[each.GetUpperBound()] = each.GetCumulativeCount()
+	}
+	return buckets
 }
 
 func findMetric(metricName string, gathered []*clientmodel.MetricFamily) *clientmodel.MetricFamily {
@@ -442,3 +691,121 @@ func assertRegistryHasMetricWithConfigTypeCounts(t *testing.T, metricLabelMap La
 		}
 	}
 }
+
+func TestSnapshot(t *testing.T) {
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc"})
+	empty, err := metrics.TestHelper().Snapshot(true)
+	assert.NoError(t, err)
+	assert.Equal(t, "", empty)
+
+	metrics.Gauge("queue").SetValue(3)
+	metrics.CounterWithLabel("requests", "method").IncLabel("POST")
+	metrics.CounterWithLabel("requests", "method").IncLabel("GET")
+
+	snapshot, err := metrics.TestHelper().Snapshot(false)
+	assert.NoError(t, err)
+	assert.Equal(t, `# HELP svc_queue svc_queue
+# TYPE svc_queue gauge
+svc_queue 3
+# HELP svc_requests svc_requests
+# TYPE svc_requests counter
+svc_requests{method="GET"} 1
+svc_requests{method="POST"} 1
+`, snapshot)
+
+	masked, err := metrics.TestHelper().Snapshot(true)
+	assert.NoError(t, err)
+	assert.Equal(t, `# HELP svc_queue svc_queue
+# TYPE svc_queue gauge
+svc_queue *
+# HELP svc_requests svc_requests
+# TYPE svc_requests counter
+svc_requests{method="*"} *
+`, masked)
+}
+
+func TestFork(t *testing.T) {
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc"})
+	metrics.Counter("shared").Inc()
+
+	for _, name := range []string{"first", "second", "third"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			fork := metrics.TestHelper().Fork(t)
+
+			fork.Counter("Requests").IncBy(3)
+			fork.Gauge(name).SetValue(1)
+			fork.Error("oops")
+
+			assert.ElementsMatch(t, []string{"svc_errors", "svc_" + name, "svc_requests"}, fork.TestHelper().MetricNames())
+			value, err := fork.TestHelper().CounterValue("Requests")
+			assert.NoError(t, err)
+			assert.Equal(t, 3.0, value)
+		})
+	}
+
+	assert.Equal(t, []string{"svc_shared"}, metrics.TestHelper().MetricNames())
+}
+
+func TestForkRecording(t *testing.T) {
+	recorder := NewRecordingBackend()
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc", Backend: recorder})
+	metrics.Counter("requests").Inc()
+
+	for _, name := range []string{"first", "second", "third"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			fork := metrics.TestHelper().Fork(t)
+			fork.Counter("requests").IncBy(3)
+
+			forked := fork.TestHelper().RecordingBackend()
+			assert.NotSame(t, recorder, forked)
+			assert.Equal(t, 3.0, forked.CounterValue("svc_requests"))
+		})
+	}
+
+	assert.Equal(t, 1.0, recorder.CounterValue("svc_requests"))
+	metrics.TestHelper().Clear()
+	assert.Empty(t, recorder.Operations())
+}
+
+func TestForkSharedBackend(t *testing.T) {
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), Backend: struct{ Backend }{NewRecordingBackend()}})
+	assert.Panics(t, func() { metrics.TestHelper().Fork(t) })
+}
+
+func TestForkCleanup(t *testing.T) {
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry()})
+	var fork *PrometheusMetricsImpl
+
+	t.Run("test", func(t *testing.T) {
+		fork = metrics.TestHelper().Fork(t)
+		fork.Counter("requests").Inc()
+		assert.Equal(t, []string{"requests"}, fork.TestHelper().MetricNames())
+	})
+
+	assert.Equal(t, []string{}, fork.TestHelper().MetricNames())
+}
+
+func TestConcurrentClear(t *testing.T) {
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), CaseSensitiveMetricNames: false})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			metrics.Counter("Requests").Inc()
+			metrics.Error("oops")
+		}
+	}()
+
+	for i := 0; i < 50; i++ {
+		metrics.TestHelper().Clear()
+	}
+	<-done
+
+	metrics.TestHelper().Clear()
+	metrics.Counter("Requests").Inc()
+	assert.Equal(t, []string{"requests"}, metrics.TestHelper().MetricNames())
+}
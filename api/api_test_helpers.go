@@ -1,10 +1,16 @@
 package api
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	clientmodel "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
 )
 
 type TestHelper struct {
@@ -15,14 +21,113 @@ func (p *PrometheusMetricsImpl) TestHelper() *TestHelper {
 	return &TestHelper{metrics: p}
 }
 
+// Clear starts again with a new registry, and forgets every metric created so far, including anything a
+// RecordingBackend recorded. It is safe to call while metrics are being used, but parallel tests should each
+// Fork their own instance instead.
 func (helper *TestHelper) Clear() {
-	helper.metrics.registry = prometheus.NewRegistry()
-	helper.metrics.registrations = newMetricRegistrations()
-	helper.metrics.errorCounter = nil
+	p := helper.metrics
+	registry := prometheus.NewRegistry()
+
+	p.registrations.Lock()
+	switch backend := p.backend.(type) {
+	case prometheusBackend:
+		p.backend = NewPrometheusBackend(registry)
+	case *RecordingBackend:
+		backend.reset()
+	}
+	p.registry = registry
+	p.registrations.internal = make(map[string]*metricEntry)
+	p.errorCounter = nil
+	p.undeclaredCounter = nil
+	p.registrations.Unlock()
+
+	p.normalisedNames.Lock()
+	p.normalisedNames.internal = make(map[string]string)
+	p.normalisedNames.Unlock()
+
+	p.slos.Lock()
+	p.slos.internal = nil
+	p.slos.Unlock()
+}
+
+// FakeClock only moves when advanced, so timers and SLO burn rates can be asserted exactly. Give it to
+// MetricOpts.Clock.
+type FakeClock struct {
+	sync.Mutex
+	now time.Time
+}
+
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) Advance(d time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Cleaner is satisfied by *testing.T and *testing.B
+type Cleaner interface {
+	Cleanup(func())
+}
+
+// Fork returns metrics configured like these, but with their own registry, registrations, error counter and
+// backend, cleared when the test finishes. A RecordingBackend is forked empty, see RecordingBackend(). Forking
+// any other backend with state, e.g. OpenTelemetry or StatsD, panics, as forks would see each other's values.
+func (helper *TestHelper) Fork(t Cleaner) *PrometheusMetricsImpl {
+	p := helper.metrics
+	registry := prometheus.NewRegistry()
+
+	p.registrations.RLock()
+	backend := p.backend
+	p.registrations.RUnlock()
+
+	switch original := backend.(type) {
+	case prometheusBackend:
+		backend = NewPrometheusBackend(registry)
+	case *RecordingBackend:
+		backend = original.fork()
+	case noopBackend:
+	default:
+		panic(fmt.Sprintf("can't Fork metrics using a %T backend, whose state would be shared", backend))
+	}
+
+	fork := &PrometheusMetricsImpl{registry: registry,
+		metricNamePrefix:         p.metricNamePrefix,
+		descriptions:             p.descriptions,
+		definitions:              p.definitions,
+		strict:                   p.strict,
+		errorPolicy:              p.errorPolicy,
+		naming:                   p.naming,
+		nameValidation:           p.nameValidation,
+		registrations:            newMetricRegistrations(),
+		clock:                    p.clock,
+		backend:                  backend,
+		caseSensitiveMetricNames: p.caseSensitiveMetricNames,
+		normalisedNames:          newNormalisedNames(),
+	}
+
+	t.Cleanup(func() { fork.TestHelper().Clear() })
+	return fork
+}
+
+// RecordingBackend returns the RecordingBackend the metrics write to, e.g. a fork's own, or nil if they use another
+func (helper *TestHelper) RecordingBackend() *RecordingBackend {
+	helper.metrics.registrations.RLock()
+	defer helper.metrics.registrations.RUnlock()
+	recording, _ := helper.metrics.backend.(*RecordingBackend)
+	return recording
 }
 
 func (helper *TestHelper) Gather() ([]*clientmodel.MetricFamily, error) {
-	return helper.metrics.registry.(prometheus.Gatherer).Gather()
+	return helper.metrics.getRegistry().(prometheus.Gatherer).Gather()
 }
 
 func (helper *TestHelper) MetricNames() []string {
@@ -77,3 +182,209 @@ func (helper *TestHelper) GetMetricFamily(metrics []*clientmodel.MetricFamily, n
 
 	return &clientmodel.MetricFamily{}, fmt.Errorf("metric %s not found in %v", name, metrics)
 }
+
+type HistogramSnapshot struct {
+	Count   uint64
+	Sum     float64
+	Buckets map[float64]uint64 // cumulative count by upper bound
+}
+
+type SummarySnapshot struct {
+	Count     uint64
+	Sum       float64
+	Quantiles map[float64]float64
+}
+
+// CounterValue gathers the current value of a counter, named as it was when created, i.e. before prefixing
+// and normalisation. Label values are in the order the label names were given.
+func (helper *TestHelper) CounterValue(name string, labelValues ...string) (float64, error) {
+	series, err := helper.findSeries(name, clientmodel.MetricType_COUNTER, labelValues)
+	if err != nil {
+		return 0, err
+	}
+	return series.GetCounter().GetValue(), nil
+}
+
+func (helper *TestHelper) GaugeValue(name string, labelValues ...string) (float64, error) {
+	series, err := helper.findSeries(name, clientmodel.MetricType_GAUGE, labelValues)
+	if err != nil {
+		return 0, err
+	}
+	return series.GetGauge().GetValue(), nil
+}
+
+func (helper *TestHelper) HistogramSnapshot(name string, labelValues ...string) (HistogramSnapshot, error) {
+	series, err := helper.findSeries(name, clientmodel.MetricType_HISTOGRAM, labelValues)
+	if err != nil {
+		return HistogramSnapshot{}, err
+	}
+
+	histogram := series.GetHistogram()
+	snapshot := HistogramSnapshot{Count: histogram.GetSampleCount(), Sum: histogram.GetSampleSum(), Buckets: map[float64]uint64{}}
+	for _, each := range histogram.GetBucket() {
+		snapshot.Buckets[each.GetUpperBound()] = each.GetCumulativeCount()
+	}
+	return snapshot, nil
+}
+
+func (helper *TestHelper) SummarySnapshot(name string, labelValues ...string) (SummarySnapshot, error) {
+	series, err := helper.findSeries(name, clientmodel.MetricType_SUMMARY, labelValues)
+	if err != nil {
+		return SummarySnapshot{}, err
+	}
+
+	summary := series.GetSummary()
+	snapshot := SummarySnapshot{Count: summary.GetSampleCount(), Sum: summary.GetSampleSum(), Quantiles: map[float64]float64{}}
+	for _, each := range summary.GetQuantile() {
+		snapshot.Quantiles[each.GetQuantile()] = each.GetValue()
+	}
+	return snapshot, nil
+}
+
+func (helper *TestHelper) findSeries(name string, metricType clientmodel.MetricType, labelValues []string) (*clientmodel.Metric, error) {
+	family, series, err := helper.Series(name, labelValues...)
+	if err != nil {
+		return nil, err
+	}
+	if family.GetType() != metricType {
+		return nil, fmt.Errorf("metric %s is a %s, not a %s", family.GetName(), family.GetType(), metricType)
+	}
+	return series, nil
+}
+
+// Series gathers the metric family for name, as named when created, and the series within it having the label values
+func (helper *TestHelper) Series(name string, labelValues ...string) (*clientmodel.MetricFamily, *clientmodel.Metric, error) {
+	fullName, labelNames := helper.describe(name)
+
+	metricFamilies, err := helper.Gather()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var family *clientmodel.MetricFamily
+	for _, each := range metricFamilies {
+		if each.GetName() == fullName {
+			family = each
+		}
+	}
+
+	if family == nil {
+		return nil, nil, fmt.Errorf("metric %s not found", fullName)
+	}
+	if len(labelValues) != len(labelNames) {
+		return family, nil, fmt.Errorf("metric %s has labels %v, but %d label values were given", fullName, labelNames, len(labelValues))
+	}
+
+	wanted := make(map[string]string, len(labelNames))
+	for i, labelName := range labelNames {
+		wanted[labelName] = labelValues[i]
+	}
+
+	for _, series := range family.GetMetric() {
+		if labelsMatch(series.GetLabel(), wanted) {
+			return family, series, nil
+		}
+	}
+
+	return family, nil, fmt.Errorf("metric %s has no series with labels {%s}", fullName, formatLabels(labelNames, labelValues))
+}
+
+// MetricName returns the full name the constructors would give a metric called name
+func (helper *TestHelper) MetricName(name string) string {
+	fullName, _ := helper.describe(name)
+	return fullName
+}
+
+// describe resolves a name as the constructors would, returning the full metric name and its label names
+func (helper *TestHelper) describe(name string) (string, []string) {
+	fullName := helper.metrics.getFullMetricName(helper.metrics.getMetricKey(name))
+
+	if entry, ok := helper.metrics.getRegistration(helper.metrics.getMetricKey(name)); ok {
+		return fullName, entry.labelNames
+	}
+	helper.metrics.registrations.RLock()
+	errorCounterName := helper.metrics.errorCounterName
+	helper.metrics.registrations.RUnlock()
+
+	if fullName == errorCounterName {
+		return fullName, []string{"error_type"}
+	}
+	return fullName, nil
+}
+
+func labelsMatch(labels []*clientmodel.LabelPair, wanted map[string]string) bool {
+	if len(labels) != len(wanted) {
+		return false
+	}
+	for _, each := range labels {
+		if value, ok := wanted[each.GetName()]; !ok || value != each.GetValue() {
+			return false
+		}
+	}
+	return true
+}
+
+func formatLabels(labelNames []string, labelValues []string) string {
+	pairs := make([]string, len(labelNames))
+	for i, labelName := range labelNames {
+		pairs[i] = fmt.Sprintf("%s=%q", labelName, labelValues[i])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// Snapshot returns the text exposition of every registered metric, sorted by name and then label values. With
+// maskValues, every sample and label value is replaced by "*", and each family collapsed to one series per set of
+// label names, so the text only changes when names, types, help strings, label names or buckets do.
+func (helper *TestHelper) Snapshot(maskValues bool) (string, error) {
+	metricFamilies, err := helper.Gather()
+	if err != nil {
+		return "", err
+	}
+
+	sort.Slice(metricFamilies, func(i, j int) bool { return metricFamilies[i].GetName() < metricFamilies[j].GetName() })
+
+	var buf bytes.Buffer
+	for _, family := range metricFamilies {
+		if maskValues {
+			family.Metric = maskLabelValues(family.Metric)
+		}
+		if _, err := expfmt.MetricFamilyToText(&buf, family); err != nil {
+			return "", err
+		}
+	}
+
+	if !maskValues || buf.Len() == 0 {
+		return buf.String(), nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "#") {
+			lines[i] = line[:strings.LastIndexByte(line, ' ')] + " *"
+		}
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// maskLabelValues replaces label values by "*", keeping the first series of each set of label names
+func maskLabelValues(metrics []*clientmodel.Metric) []*clientmodel.Metric {
+	masked := "*"
+	seen := map[string]bool{}
+	var collapsed []*clientmodel.Metric
+	for _, each := range metrics {
+		names := make([]string, len(each.Label))
+		labels := make([]*clientmodel.LabelPair, len(each.Label))
+		for i, label := range each.Label {
+			names[i] = label.GetName()
+			labels[i] = &clientmodel.LabelPair{Name: label.Name, Value: &masked} // the registry's own are shared
+		}
+
+		key := strings.Join(names, ",")
+		if !seen[key] {
+			seen[key] = true
+			collapsed = append(collapsed, &clientmodel.Metric{Label: labels, Gauge: each.Gauge, Counter: each.Counter,
+				Summary: each.Summary, Untyped: each.Untyped, Histogram: each.Histogram, TimestampMs: each.TimestampMs})
+		}
+	}
+	return collapsed
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type prometheusBackend struct {
+	registry prometheus.Registerer
+}
+
+// NewPrometheusBackend returns the default Backend, which creates Prometheus client metrics and registers them
+// with registry. Timers are summaries.
+func NewPrometheusBackend(registry prometheus.Registerer) Backend {
+	return prometheusBackend{registry: registry}
+}
+
+func (b prometheusBackend) NewCounter(opts InstrumentOpts) CounterInstrument {
+	internal := prometheus.NewCounter(prometheus.CounterOpts{Name: opts.Name, Help: opts.Help, Unit: opts.Unit})
+	b.registry.Register(internal)
+	return internal
+}
+
+func (b prometheusBackend) NewCounterVec(opts InstrumentOpts) CounterVecInstrument {
+	internal := prometheus.NewCounterVec(prometheus.CounterOpts{Name: opts.Name, Help: opts.Help, Unit: opts.Unit}, opts.LabelNames)
+	b.registry.Register(internal)
+	return promCounterVec{internal}
+}
+
+func (b prometheusBackend) NewGauge(opts InstrumentOpts) GaugeInstrument {
+	internal := prometheus.NewGauge(prometheus.GaugeOpts{Name: opts.Name, Help: opts.Help, Unit: opts.Unit})
+	b.registry.Register(internal)
+	return internal
+}
+
+func (b prometheusBackend) NewGaugeVec(opts InstrumentOpts) GaugeVecInstrument {
+	internal := &promGaugeVec{GaugeVec: prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: opts.Name, Help: opts.Help, Unit: opts.Unit}, opts.LabelNames)}
+	b.registry.Register(internal)
+	return internal
+}
+
+func (b prometheusBackend) NewHistogram(opts InstrumentOpts) ObserverInstrument {
+	internal := prometheus.NewHistogram(prometheus.HistogramOpts{Name: opts.Name, Help: opts.Help, Unit: opts.Unit, Buckets: opts.Buckets})
+	b.registry.Register(internal)
+	return internal
+}
+
+func (b prometheusBackend) NewSummary(opts InstrumentOpts) ObserverInstrument {
+	internal := prometheus.NewSummary(prometheus.SummaryOpts{Name: opts.Name, Help: opts.Help, Unit: opts.Unit, Objectives: DefaultObjectives})
+	b.registry.Register(internal)
+	return internal
+}
+
+func (b prometheusBackend) NewSummaryVec(opts InstrumentOpts) ObserverVecInstrument {
+	internal := prometheus.NewSummaryVec(prometheus.SummaryOpts{Name: opts.Name, Help: opts.Help, Unit: opts.Unit, Objectives: DefaultObjectives}, opts.LabelNames)
+	b.registry.Register(internal)
+	return promSummaryVec{internal}
+}
+
+func (b prometheusBackend) NewTimer(opts InstrumentOpts) ObserverInstrument {
+	return b.NewSummary(opts)
+}
+
+func (b prometheusBackend) NewTimerVec(opts InstrumentOpts) ObserverVecInstrument {
+	return b.NewSummaryVec(opts)
+}
+
+type promCounterVec struct {
+	*prometheus.CounterVec
+}
+
+func (v promCounterVec) WithLabelValues(labelValues ...string) CounterInstrument {
+	return v.CounterVec.WithLabelValues(labelValues...)
+}
+
+// promGaugeVec runs any hooks before each collection, so gauges computed from other state, e.g. SLO burn rates,
+// are current when scraped
+type promGaugeVec struct {
+	*prometheus.GaugeVec
+
+	hooksLock sync.Mutex
+	hooks     []func()
+}
+
+func (v *promGaugeVec) WithLabelValues(labelValues ...string) GaugeInstrument {
+	return v.GaugeVec.WithLabelValues(labelValues...)
+}
+
+func (v *promGaugeVec) Collect(ch chan<- prometheus.Metric) {
+	v.hooksLock.Lock()
+	hooks := v.hooks
+	v.hooksLock.Unlock()
+
+	for _, each := range hooks {
+		each()
+	}
+	v.GaugeVec.Collect(ch)
+}
+
+func (v *promGaugeVec) beforeCollect(hook func()) bool {
+	v.hooksLock.Lock()
+	defer v.hooksLock.Unlock()
+	v.hooks = append(v.hooks, hook)
+	return true
+}
+
+type promSummaryVec struct {
+	*prometheus.SummaryVec
+}
+
+func (v promSummaryVec) WithLabelValues(labelValues ...string) ObserverInstrument {
+	return v.SummaryVec.WithLabelValues(labelValues...)
+}
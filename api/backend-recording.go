@@ -0,0 +1,292 @@
+package api
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordingBackend keeps every operation in memory, so tests can assert on values rather than exposition text.
+// Queries take the full metric name, i.e. including any prefix, and return zero values for unknown series.
+// Operations are timed by the Clock of the metrics using it.
+type RecordingBackend struct {
+	sync.Mutex
+	clock      Clock
+	operations []RecordedOperation
+	series     map[string]*recordedSeries
+	buckets    map[string][]float64
+}
+
+type RecordedOperation struct {
+	Time        time.Time
+	Name        string
+	LabelValues []string
+	Operation   string // Inc, Add, Set, Dec, Sub or Observe
+	Value       float64
+}
+
+type recordedSeries struct {
+	value        float64
+	observations []float64
+}
+
+func NewRecordingBackend() *RecordingBackend {
+	return &RecordingBackend{clock: systemClock{}, series: make(map[string]*recordedSeries), buckets: make(map[string][]float64)}
+}
+
+// useClock is called by NewMetrics, so recorded times match the timers'
+func (b *RecordingBackend) useClock(clock Clock) {
+	b.Lock()
+	defer b.Unlock()
+	b.clock = clock
+}
+
+// fork returns an empty backend with the same clock, for TestHelper.Fork
+func (b *RecordingBackend) fork() *RecordingBackend {
+	b.Lock()
+	defer b.Unlock()
+	forked := NewRecordingBackend()
+	forked.clock = b.clock
+	return forked
+}
+
+// reset forgets everything recorded, for TestHelper.Clear
+func (b *RecordingBackend) reset() {
+	b.Lock()
+	defer b.Unlock()
+	b.operations = nil
+	b.series = make(map[string]*recordedSeries)
+	b.buckets = make(map[string][]float64)
+}
+
+// Operations returns everything recorded so far, oldest first
+func (b *RecordingBackend) Operations() []RecordedOperation {
+	b.Lock()
+	defer b.Unlock()
+	return append([]RecordedOperation(nil), b.operations...)
+}
+
+func (b *RecordingBackend) CounterValue(name string, labelValues ...string) float64 {
+	return b.value(name, labelValues)
+}
+
+func (b *RecordingBackend) GaugeValue(name string, labelValues ...string) float64 {
+	return b.value(name, labelValues)
+}
+
+func (b *RecordingBackend) HistogramCount(name string, labelValues ...string) uint64 {
+	return uint64(len(b.observations(name, labelValues)))
+}
+
+func (b *RecordingBackend) HistogramSum(name string, labelValues ...string) float64 {
+	return sum(b.observations(name, labelValues))
+}
+
+// HistogramBuckets returns the cumulative count for each upper bound the histogram was created with
+func (b *RecordingBackend) HistogramBuckets(name string, labelValues ...string) map[float64]uint64 {
+	observations := b.observations(name, labelValues)
+
+	b.Lock()
+	bounds := b.buckets[name]
+	b.Unlock()
+
+	counts := make(map[float64]uint64, len(bounds))
+	for _, bound := range bounds {
+		counts[bound] = 0
+		for _, each := range observations {
+			if each <= bound {
+				counts[bound]++
+			}
+		}
+	}
+	return counts
+}
+
+func (b *RecordingBackend) SummaryCount(name string, labelValues ...string) uint64 {
+	return uint64(len(b.observations(name, labelValues)))
+}
+
+func (b *RecordingBackend) SummarySum(name string, labelValues ...string) float64 {
+	return sum(b.observations(name, labelValues))
+}
+
+// SummaryQuantiles returns the exact value at each of the DefaultObjectives, by nearest rank
+func (b *RecordingBackend) SummaryQuantiles(name string, labelValues ...string) map[float64]float64 {
+	observations := b.observations(name, labelValues)
+	sort.Float64s(observations)
+
+	quantiles := make(map[float64]float64, len(DefaultObjectives))
+	for quantile := range DefaultObjectives {
+		if len(observations) == 0 {
+			quantiles[quantile] = math.NaN()
+			continue
+		}
+		rank := int(math.Ceil(quantile*float64(len(observations)))) - 1
+		quantiles[quantile] = observations[max(rank, 0)]
+	}
+	return quantiles
+}
+
+func (b *RecordingBackend) value(name string, labelValues []string) float64 {
+	b.Lock()
+	defer b.Unlock()
+	if series, ok := b.series[seriesKey(name, labelValues)]; ok {
+		return series.value
+	}
+	return 0
+}
+
+func (b *RecordingBackend) observations(name string, labelValues []string) []float64 {
+	b.Lock()
+	defer b.Unlock()
+	if series, ok := b.series[seriesKey(name, labelValues)]; ok {
+		return append([]float64(nil), series.observations...)
+	}
+	return nil
+}
+
+func (b *RecordingBackend) record(name string, labelValues []string, operation string, value float64) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.operations = append(b.operations, RecordedOperation{Time: b.clock.Now(), Name: name, LabelValues: labelValues, Operation: operation, Value: value})
+
+	key := seriesKey(name, labelValues)
+	series, ok := b.series[key]
+	if !ok {
+		series = &recordedSeries{}
+		b.series[key] = series
+	}
+
+	switch operation {
+	case "Set":
+		series.value = value
+	case "Observe":
+		series.observations = append(series.observations, value)
+	case "Dec", "Sub":
+		series.value -= value
+	default:
+		series.value += value
+	}
+}
+
+func seriesKey(name string, labelValues []string) string {
+	return name + "\xff" + strings.Join(labelValues, "\xff")
+}
+
+func sum(values []float64) float64 {
+	total := 0.0
+	for _, each := range values {
+		total += each
+	}
+	return total
+}
+
+func (b *RecordingBackend) NewCounter(opts InstrumentOpts) CounterInstrument {
+	return recordingInstrument{backend: b, name: opts.Name}
+}
+
+func (b *RecordingBackend) NewCounterVec(opts InstrumentOpts) CounterVecInstrument {
+	return recordingCounterVec{recordingVec{backend: b, name: opts.Name, labelNames: opts.LabelNames}}
+}
+
+func (b *RecordingBackend) NewGauge(opts InstrumentOpts) GaugeInstrument {
+	return recordingInstrument{backend: b, name: opts.Name}
+}
+
+func (b *RecordingBackend) NewGaugeVec(opts InstrumentOpts) GaugeVecInstrument {
+	return recordingGaugeVec{recordingVec{backend: b, name: opts.Name, labelNames: opts.LabelNames}}
+}
+
+func (b *RecordingBackend) NewHistogram(opts InstrumentOpts) ObserverInstrument {
+	b.Lock()
+	b.buckets[opts.Name] = opts.Buckets
+	b.Unlock()
+	return recordingInstrument{backend: b, name: opts.Name}
+}
+
+func (b *RecordingBackend) NewSummary(opts InstrumentOpts) ObserverInstrument {
+	return recordingInstrument{backend: b, name: opts.Name}
+}
+
+func (b *RecordingBackend) NewSummaryVec(opts InstrumentOpts) ObserverVecInstrument {
+	return recordingObserverVec{recordingVec{backend: b, name: opts.Name, labelNames: opts.LabelNames}}
+}
+
+func (b *RecordingBackend) NewTimer(opts InstrumentOpts) ObserverInstrument {
+	return b.NewSummary(opts)
+}
+
+func (b *RecordingBackend) NewTimerVec(opts InstrumentOpts) ObserverVecInstrument {
+	return b.NewSummaryVec(opts)
+}
+
+type recordingInstrument struct {
+	backend     *RecordingBackend
+	name        string
+	labelValues []string
+}
+
+func (i recordingInstrument) Inc() {
+	i.backend.record(i.name, i.labelValues, "Inc", 1)
+}
+
+func (i recordingInstrument) Dec() {
+	i.backend.record(i.name, i.labelValues, "Dec", 1)
+}
+
+func (i recordingInstrument) Add(inc float64) {
+	i.backend.record(i.name, i.labelValues, "Add", inc)
+}
+
+func (i recordingInstrument) Sub(dec float64) {
+	i.backend.record(i.name, i.labelValues, "Sub", dec)
+}
+
+func (i recordingInstrument) Set(value float64) {
+	i.backend.record(i.name, i.labelValues, "Set", value)
+}
+
+func (i recordingInstrument) Observe(value float64) {
+	i.backend.record(i.name, i.labelValues, "Observe", value)
+}
+
+type recordingVec struct {
+	backend    *RecordingBackend
+	name       string
+	labelNames []string
+}
+
+func (v recordingVec) child(labelValues []string) recordingInstrument {
+	if len(labelValues) != len(v.labelNames) {
+		panic("expected " + strconv.Itoa(len(v.labelNames)) + " label values for " + v.name + " but got " + strconv.Itoa(len(labelValues)))
+	}
+	return recordingInstrument{backend: v.backend, name: v.name, labelValues: append([]string(nil), labelValues...)}
+}
+
+type recordingCounterVec struct {
+	recordingVec
+}
+
+func (v recordingCounterVec) WithLabelValues(labelValues ...string) CounterInstrument {
+	return v.child(labelValues)
+}
+
+type recordingGaugeVec struct {
+	recordingVec
+}
+
+func (v recordingGaugeVec) WithLabelValues(labelValues ...string) GaugeInstrument {
+	return v.child(labelValues)
+}
+
+type recordingObserverVec struct {
+	recordingVec
+}
+
+func (v recordingObserverVec) WithLabelValues(labelValues ...string) ObserverInstrument {
+	return v.child(labelValues)
+}
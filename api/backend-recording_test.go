@@ -0,0 +1,87 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordingCountersAndGauges(t *testing.T) {
+	recorder := NewRecordingBackend()
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "z", Backend: recorder})
+
+	before := time.Now()
+	c := metrics.Counter("Mine")
+	c.Inc()
+	c.IncBy(7)
+	metrics.CounterWithLabels("animals", []string{"animal", "breed"}).IncLabelBy("cat", "persian").Value(3)
+	metrics.Error("bad")
+
+	g := metrics.GaugeWithLabel("current animals", "animal")
+	g.SetLabels("fleas").Value(1000)
+	g.DecLabelsBy("fleas").Value(15)
+	g.IncLabels("dog")
+	metrics.Gauge("g").Dec()
+
+	assert.Equal(t, 8.0, recorder.CounterValue("z_mine"))
+	assert.Equal(t, 3.0, recorder.CounterValue("z_animals", "cat", "persian"))
+	assert.Equal(t, 0.0, recorder.CounterValue("z_animals", "dog", "mutt"))
+	assert.Equal(t, 1.0, recorder.CounterValue("z_errors", "bad"))
+	assert.Equal(t, 985.0, recorder.GaugeValue("z_current_animals", "fleas"))
+	assert.Equal(t, 1.0, recorder.GaugeValue("z_current_animals", "dog"))
+	assert.Equal(t, -1.0, recorder.GaugeValue("z_g"))
+
+	operations := recorder.Operations()
+	assert.Len(t, operations, 8)
+	assert.Equal(t, RecordedOperation{Time: operations[4].Time, Name: "z_current_animals", LabelValues: []string{"fleas"}, Operation: "Set", Value: 1000}, operations[4])
+	assert.Equal(t, "Sub", operations[5].Operation)
+	assert.Equal(t, 15.0, operations[5].Value)
+	assert.False(t, operations[0].Time.Before(before))
+
+	assert.Panics(t, func() { metrics.CounterWithLabels("animals", []string{"animal", "breed"}).IncLabel("cat") })
+}
+
+func TestRecordingHistogramsAndSummaries(t *testing.T) {
+	recorder := NewRecordingBackend()
+	clock := NewFakeClock(testStart)
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "A", Backend: recorder, Clock: clock})
+
+	h := metrics.Histogram("MyHisto", []float64{2.0, 3.0, 3.5})
+	s := metrics.Summary("MySummary")
+	for _, each := range []float64{1.3, 2.5, 2.6, 2.9, 3.2, 3.3, 3.834344} {
+		h.Update(each)
+		s.Observe(each)
+	}
+	timedFor(clock, 2*time.Second, metrics.TimerWithLabel("animal_timer", "animal", "cat"))
+
+	assert.Equal(t, uint64(7), recorder.HistogramCount("a_myhisto"))
+	assert.InDelta(t, 19.634344, recorder.HistogramSum("a_myhisto"), 1e-9)
+	assert.Equal(t, map[float64]uint64{2.0: 1, 3.0: 4, 3.5: 6}, recorder.HistogramBuckets("a_myhisto"))
+
+	assert.Equal(t, uint64(7), recorder.SummaryCount("a_mysummary"))
+	assert.Equal(t, map[float64]float64{0.5: 2.9, 0.75: 3.3, 0.9: 3.834344, 0.95: 3.834344, 0.99: 3.834344, 0.999: 3.834344}, recorder.SummaryQuantiles("a_mysummary"))
+
+	assert.Equal(t, uint64(1), recorder.SummaryCount("a_animal_timer", "cat"))
+	assert.Equal(t, 2.0, recorder.SummarySum("a_animal_timer", "cat"))
+	assert.Zero(t, recorder.HistogramCount("a_unknown"))
+}
+
+func TestRecordingUsesClock(t *testing.T) {
+	recorder := NewRecordingBackend()
+	clock := NewFakeClock(testStart)
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), Backend: recorder, Clock: clock})
+
+	metrics.Counter("before").Inc()
+	timedFor(clock, 3*time.Second, metrics.Timer("work"))
+
+	fork := metrics.TestHelper().Fork(t)
+	fork.Counter("forked").Inc()
+
+	operations := recorder.Operations()
+	assert.Len(t, operations, 2)
+	assert.Equal(t, testStart, operations[0].Time)
+	assert.Equal(t, testStart.Add(3*time.Second), operations[1].Time)
+	assert.Equal(t, testStart.Add(3*time.Second), fork.TestHelper().RecordingBackend().Operations()[0].Time)
+}
@@ -0,0 +1,55 @@
+package api
+
+// Backend creates the instruments that each facade delegates to, so the same calls can be recorded by
+// Prometheus (the default), OpenTelemetry, StatsD or anything else. Names arrive fully prefixed and normalised.
+type Backend interface {
+	NewCounter(opts InstrumentOpts) CounterInstrument
+	NewCounterVec(opts InstrumentOpts) CounterVecInstrument
+	NewGauge(opts InstrumentOpts) GaugeInstrument
+	NewGaugeVec(opts InstrumentOpts) GaugeVecInstrument
+	NewHistogram(opts InstrumentOpts) ObserverInstrument
+	NewSummary(opts InstrumentOpts) ObserverInstrument
+	NewSummaryVec(opts InstrumentOpts) ObserverVecInstrument
+	NewTimer(opts InstrumentOpts) ObserverInstrument // observes seconds
+	NewTimerVec(opts InstrumentOpts) ObserverVecInstrument
+}
+
+type InstrumentOpts struct {
+	Name       string
+	Help       string
+	LabelNames []string  // only for the Vec instruments
+	Buckets    []float64 // only for histograms
+	Unit       string    // e.g. seconds, only when given by WithUnit, and already a suffix of Name
+}
+
+// The minimal behaviour each facade needs from its instrument. The Prometheus client types satisfy these
+// directly; their Vec types need a thin adapter to return our own interfaces.
+
+type CounterInstrument interface {
+	Inc()
+	Add(float64)
+}
+
+type CounterVecInstrument interface {
+	WithLabelValues(labelValues ...string) CounterInstrument
+}
+
+type GaugeInstrument interface {
+	Set(float64)
+	Inc()
+	Dec()
+	Add(float64)
+	Sub(float64)
+}
+
+type GaugeVecInstrument interface {
+	WithLabelValues(labelValues ...string) GaugeInstrument
+}
+
+type ObserverInstrument interface {
+	Observe(float64)
+}
+
+type ObserverVecInstrument interface {
+	WithLabelValues(labelValues ...string) ObserverInstrument
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	CatalogueCounter   = "counter"
+	CatalogueGauge     = "gauge"
+	CatalogueHistogram = "histogram"
+	CatalogueSummary   = "summary"
+	CatalogueTimer     = "timer"
+)
+
+// Catalogue declares metrics in a file that can be reviewed, and shared with dashboards and alerting rules
+type Catalogue struct {
+	Metrics []MetricDefinition `yaml:"metrics" json:"metrics"`
+}
+
+// MetricDefinition describes one metric. Name is as passed to the constructors, i.e. without any prefix, except
+// when describing exposed metrics, as PrometheusMetricsImpl.Catalogue does.
+type MetricDefinition struct {
+	Name    string    `yaml:"name" json:"name"`
+	Help    string    `yaml:"help,omitempty" json:"help,omitempty"`
+	Type    string    `yaml:"type" json:"type"` // counter, gauge, histogram, summary or timer
+	Labels  []string  `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Buckets []float64 `yaml:"buckets,omitempty" json:"buckets,omitempty"` // histograms only, replacing those given in code
+	Unit    string    `yaml:"unit,omitempty" json:"unit,omitempty"`       // applied as WithUnit would, unless given in code
+	Owner   string    `yaml:"owner,omitempty" json:"owner,omitempty"`
+}
+
+// NameWithUnit is Name with the declared unit added, as the constructors expose it
+func (d MetricDefinition) NameWithUnit() string {
+	return d.unit().suffix(d.Name)
+}
+
+// unit is the declared unit, except that seconds are implied for timers and histograms, as when created in code
+func (d MetricDefinition) unit() Unit {
+	unit := unitNamed(d.Unit)
+	if unit == Seconds && (d.Type == CatalogueTimer || d.Type == CatalogueHistogram) {
+		unit.implied = true
+	}
+	return unit
+}
+
+// LoadCatalogue reads a YAML or JSON catalogue file
+func LoadCatalogue(path string) (*Catalogue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	catalogue, err := ParseCatalogue(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return catalogue, nil
+}
+
+// ParseCatalogue parses YAML, or JSON as a subset of it, and checks every definition
+func ParseCatalogue(data []byte) (*Catalogue, error) {
+	var catalogue Catalogue
+	if err := yaml.Unmarshal(data, &catalogue); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(catalogue.Metrics))
+	for _, each := range catalogue.Metrics {
+		if each.Name == "" {
+			return nil, fmt.Errorf("catalogue metric has no name")
+		}
+
+		key := NormaliseAndLowercaseName(each.Name)
+		if seen[key] {
+			return nil, fmt.Errorf("catalogue metric %s is declared more than once", each.Name)
+		}
+		seen[key] = true
+
+		switch each.Type {
+		case CatalogueCounter, CatalogueGauge, CatalogueSummary, CatalogueTimer:
+			if len(each.Buckets) > 0 {
+				return nil, fmt.Errorf("catalogue metric %s is a %s, so cannot have buckets", each.Name, each.Type)
+			}
+		case CatalogueHistogram:
+			if len(each.Labels) > 0 {
+				return nil, fmt.Errorf("catalogue metric %s: labelled histograms are not supported", each.Name)
+			}
+		default:
+			return nil, fmt.Errorf("catalogue metric %s has unknown type %q", each.Name, each.Type)
+		}
+	}
+	return &catalogue, nil
+}
+
+// NewMetricsFromCatalogue loads the catalogue at path and creates metrics using it, as MetricOpts.Catalogue
+func NewMetricsFromCatalogue(path string, opts MetricOpts) (PrometheusMetricsImpl, error) {
+	catalogue, err := LoadCatalogue(path)
+	if err != nil {
+		return PrometheusMetricsImpl{}, err
+	}
+
+	opts.Catalogue = catalogue
+	return NewMetrics(opts), nil
+}
+
+// definitions indexes the catalogue by metric key, both as given and with any unit added, including the metrics
+// each timer creates for itself
+func (c *Catalogue) definitions(caseSensitive bool, validation NameValidation) map[string]MetricDefinition {
+	definitions := make(map[string]MetricDefinition)
+	if c == nil {
+		return definitions
+	}
+
+	for _, each := range c.Metrics {
+		for _, definition := range append([]MetricDefinition{each}, each.companions()...) {
+			definitions[normaliseName(definition.Name, caseSensitive, validation)] = definition
+			definitions[normaliseName(definition.NameWithUnit(), caseSensitive, validation)] = definition
+		}
+	}
+	return definitions
+}
+
+// companions are the metrics a timer creates as needed, so declaring the timer declares them: its _laps summary,
+// labelled as the timer plus lap, and the _calls_total counter of Time
+func (d MetricDefinition) companions() []MetricDefinition {
+	if d.Type != CatalogueTimer {
+		return nil
+	}
+	return []MetricDefinition{
+		{Name: d.Name + "_laps", Type: CatalogueTimer, Labels: append(append([]string(nil), d.Labels...), "lap"), Unit: d.Unit, Owner: d.Owner},
+		{Name: d.Name + "_calls_total", Type: CatalogueCounter, Labels: []string{"outcome"}, Owner: d.Owner},
+	}
+}
+
+// descriptions adds help text from the catalogue to those given in code, which take precedence
+func (c *Catalogue) descriptions(definitions map[string]MetricDefinition, given MetricDescriptions) MetricDescriptions {
+	if c == nil {
+		return given
+	}
+
+	merged := make(MetricDescriptions, len(definitions)+len(given))
+	for key, each := range definitions {
+		if each.Help != "" {
+			merged[key] = each.Help
+		}
+	}
+	for key, each := range given {
+		merged[key] = each
+	}
+	return merged
+}
+
+// declaredUnit returns the catalogue's unit for a metric created without one in code
+func (p *PrometheusMetricsImpl) declaredUnit(name string, unit Unit) Unit {
+	if (unit.name != "" && !unit.implied) || len(p.definitions) == 0 {
+		return unit
+	}
+	if definition, ok := p.definitions[p.getMetricKey(name)]; ok && definition.Unit != "" {
+		return definition.unit()
+	}
+	return unit
+}
+
+// declaredBuckets returns the catalogue's buckets for a histogram, if any, otherwise those given in code
+func (p *PrometheusMetricsImpl) declaredBuckets(name string, buckets []float64) []float64 {
+	if len(p.definitions) == 0 {
+		return buckets
+	}
+	if definition, ok := p.definitions[p.getMetricKey(name)]; ok && len(definition.Buckets) > 0 {
+		return definition.Buckets
+	}
+	return buckets
+}
+
+// Catalogue describes every metric created so far, with full names as exposed, for generating dashboards and
+// rules. Owner and buckets are only known for metrics declared in MetricOpts.Catalogue, and units for those
+// declared, created WithUnit, or timed.
+func (p *PrometheusMetricsImpl) Catalogue() *Catalogue {
+	p.registrations.RLock()
+	defer p.registrations.RUnlock()
+
+	catalogue := &Catalogue{}
+	for key, entry := range p.registrations.internal {
+		definition := MetricDefinition{Name: p.getFullMetricName(key), Help: entry.help, Type: typeNames[entry.metricType], Labels: entry.labelNames, Unit: entry.unit}
+		if declared, ok := p.definitions[key]; ok {
+			definition.Type = declared.Type // distinguishes timers from summaries
+			definition.Buckets = declared.Buckets
+			definition.Owner = declared.Owner
+			if declared.Unit != "" {
+				definition.Unit = declared.Unit
+			}
+		}
+		catalogue.Metrics = append(catalogue.Metrics, definition)
+	}
+
+	if p.errorCounter != nil {
+		catalogue.Metrics = append(catalogue.Metrics, MetricDefinition{Name: p.errorCounterName, Help: p.errorCounterName, Type: CatalogueCounter, Labels: []string{"error_type"}})
+	}
+
+	sort.Slice(catalogue.Metrics, func(i, j int) bool { return catalogue.Metrics[i].Name < catalogue.Metrics[j].Name })
+	return catalogue
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadCatalogue(t *testing.T) {
+	for _, path := range []string{"testdata/catalogue.yaml", "testdata/catalogue.json"} {
+		catalogue, err := LoadCatalogue(path)
+		assert.NoError(t, err)
+		assert.Equal(t, []MetricDefinition{
+			{Name: "requests", Help: "HTTP requests served", Type: CatalogueCounter, Labels: []string{"method"}, Owner: "web-team"},
+			{Name: "queue-size", Help: "Jobs waiting", Type: CatalogueGauge},
+			{Name: "payload", Help: "Request payload size", Type: CatalogueHistogram, Buckets: []float64{100, 1000, 10000}, Unit: "bytes"},
+		}, catalogue.Metrics, path)
+	}
+
+	_, err := LoadCatalogue("testdata/missing.yaml")
+	assert.Error(t, err)
+}
+
+func TestInvalidCatalogues(t *testing.T) {
+	for yaml, expected := range map[string]string{
+		"metrics: [{type: counter}]":                                  "catalogue metric has no name",
+		"metrics: [{name: a, type: counter}, {name: A, type: gauge}]": "catalogue metric A is declared more than once",
+		"metrics: [{name: a, type: meter}]":                           `catalogue metric a has unknown type "meter"`,
+		"metrics: [{name: a, type: gauge, buckets: [1]}]":             "catalogue metric a is a gauge, so cannot have buckets",
+		"metrics: [{name: a, type: histogram, labels: [x]}]":          "catalogue metric a: labelled histograms are not supported",
+		"metrics: {name: a}":                                          "yaml: unmarshal errors:\n  line 1: cannot unmarshal !!map into []api.MetricDefinition",
+	} {
+		_, err := ParseCatalogue([]byte(yaml))
+		assert.EqualError(t, err, expected, yaml)
+	}
+
+	_, err := ParseCatalogue([]byte("metrics: [{name: a, type: timer, labels: [x, y]}]"))
+	assert.NoError(t, err)
+}
+
+func TestNewMetricsFromCatalogue(t *testing.T) {
+	metrics, err := NewMetricsFromCatalogue("testdata/catalogue.yaml", MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc",
+		Descriptions: MetricDescriptions{"requests": "Overridden in code"}})
+	assert.NoError(t, err)
+
+	metrics.CounterWithLabel("requests", "method").IncLabel("GET")
+	metrics.Gauge("Queue Size").SetValue(2)
+	metrics.Histogram("payload", []float64{1, 2}).Update(500)
+	metrics.Gauge("undeclared").SetValue(1)
+
+	snapshot, err := metrics.TestHelper().Snapshot(false)
+	assert.NoError(t, err)
+	assert.Equal(t, `# HELP svc_payload_bytes Request payload size
+# TYPE svc_payload_bytes histogram
+svc_payload_bytes_bucket{le="100"} 0
+svc_payload_bytes_bucket{le="1000"} 1
+svc_payload_bytes_bucket{le="10000"} 1
+svc_payload_bytes_bucket{le="+Inf"} 1
+svc_payload_bytes_sum 500
+svc_payload_bytes_count 1
+# HELP svc_queue_size Jobs waiting
+# TYPE svc_queue_size gauge
+svc_queue_size 2
+# HELP svc_requests Overridden in code
+# TYPE svc_requests counter
+svc_requests{method="GET"} 1
+# HELP svc_undeclared svc_undeclared
+# TYPE svc_undeclared gauge
+svc_undeclared 1
+`, snapshot)
+
+	_, err = NewMetricsFromCatalogue("testdata/missing.yaml", MetricOpts{})
+	assert.Error(t, err)
+}
+
+func TestStrictCatalogue(t *testing.T) {
+	catalogue, err := LoadCatalogue("testdata/catalogue.yaml")
+	assert.NoError(t, err)
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc", Catalogue: catalogue, Strict: true,
+		Descriptions: MetricDescriptions{"legacy": "Described in code only"}})
+
+	assert.PanicsWithValue(t, "svc_reqests is not declared", func() { metrics.Counter("reqests") })
+	assert.PanicsWithValue(t, "svc_queue_size is declared as a gauge, not a counter", func() { metrics.Counter("queue-size") })
+	assert.PanicsWithValue(t, "svc_requests is declared with labels [method], not [verb]", func() { metrics.CounterWithLabel("requests", "verb") })
+	assert.PanicsWithValue(t, "svc_requests is declared with labels [method], not []", func() { metrics.Counter("requests") })
+
+	metrics.CounterWithLabel("requests", "method").IncLabel("GET")
+	metrics.Gauge("queue-size").SetValue(1)
+	metrics.HistogramForResponseTime("payload").Update(1)
+	metrics.Summary("legacy").Observe(1)
+
+	value, err := metrics.TestHelper().CounterValue("requests", "GET")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, value)
+
+	m := findMetric(undeclaredMetricName, metrics.gatherOK(t))
+	assert.Equal(t, 3, len(m.Metric))
+	assert.Equal(t, 2.0, m.Metric[2].GetCounter().GetValue())
+	assert.Equal(t, "svc_requests", m.Metric[2].GetLabel()[0].GetValue())
+}
+
+func TestStrictTimers(t *testing.T) {
+	catalogue, err := ParseCatalogue([]byte("metrics: [{name: calc, type: timer}, {name: fetch, type: timer, labels: [source]}]"))
+	assert.NoError(t, err)
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), Catalogue: catalogue, Strict: true})
+
+	assert.PanicsWithValue(t, "calc is declared as a timer, not a gauge", func() { metrics.Gauge("calc") })
+	assert.PanicsWithValue(t, "calc_laps is declared as a timer, not a counter", func() { metrics.Counter("calc_laps") })
+	metrics.Timer("calc").Stop()
+	metrics.TimerWithLabel("fetch", "source", "db").Stop()
+	assert.Equal(t, []string{"calc", "fetch", undeclaredMetricName}, metrics.TestHelper().MetricNames())
+
+	stopwatch := metrics.TimerWithLabel("fetch", "source", "db")
+	assert.NotPanics(t, func() { stopwatch.Lap("connect") })
+	stopwatch.Stop()
+	assert.NotPanics(t, func() { metrics.Timer("calc").Lap("parse") })
+
+	laps, err := metrics.TestHelper().SummarySnapshot("fetch_laps", "db", "connect")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), laps.Count)
+}
+
+func TestStrictSLO(t *testing.T) {
+	catalogue, err := ParseCatalogue([]byte("metrics: [{name: calc, type: timer}]"))
+	assert.NoError(t, err)
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc", Catalogue: catalogue, Strict: true})
+
+	assert.NotPanics(t, func() { metrics.SLO("checkout", 0.99, 0).Record(time.Millisecond, nil) })
+	assertValue(t, 1)(metrics.TestHelper().CounterValue("slo_good_events_total", "checkout"))
+	assert.NotContains(t, metrics.TestHelper().MetricNames(), undeclaredMetricName)
+}
+
+func TestErrorPolicies(t *testing.T) {
+	for _, policy := range []ErrorPolicy{LogOnError, IgnoreOnError} {
+		metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), Strict: true, ErrorPolicy: policy,
+			Descriptions: MetricDescriptions{"declared": ""}})
+
+		// all of these are discarded
+		metrics.Counter("undeclared").Inc()
+		metrics.CounterWithLabel("undeclared", "a").IncLabel("x")
+		metrics.Gauge("undeclared").SetValue(1)
+		metrics.HistogramForResponseTime("undeclared").Update(1)
+		metrics.SummaryWithLabel("undeclared", "a").Observe(1, "x")
+		metrics.TimerWithLabel("undeclared", "a", "x").Stop()
+
+		metrics.Counter("declared").Inc()
+		metrics.Gauge("declared").Inc()
+
+		assert.Equal(t, []string{"declared", undeclaredMetricName}, metrics.TestHelper().MetricNames())
+		value, err := metrics.TestHelper().CounterValue("declared")
+		assert.NoError(t, err)
+		assert.Equal(t, 1.0, value)
+
+		m := findMetric(undeclaredMetricName, metrics.gatherOK(t))
+		assert.Equal(t, 6.0, m.Metric[0].GetCounter().GetValue())
+	}
+}
+
+func TestRegisteredCatalogue(t *testing.T) {
+	catalogue, err := ParseCatalogue([]byte("metrics: [{name: calc, type: timer, owner: maths, unit: seconds}, {name: sizes, type: histogram, buckets: [1, 2]}]"))
+	assert.NoError(t, err)
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc", Catalogue: catalogue})
+
+	assert.Equal(t, &Catalogue{}, metrics.Catalogue())
+
+	metrics.Timer("calc").Stop()
+	metrics.Histogram("sizes", nil).Update(1)
+	metrics.CounterWithLabels("Animals", []string{"type", "breed"}, "Animals seen")
+	metrics.Summary("durations")
+	metrics.Error("oops")
+
+	assert.Equal(t, []MetricDefinition{
+		{Name: "svc_animals", Help: "Animals seen", Type: "counter", Labels: []string{"type", "breed"}},
+		{Name: "svc_calc", Help: "svc_calc", Type: "timer", Unit: "seconds", Owner: "maths"},
+		{Name: "svc_durations", Help: "svc_durations", Type: "summary"},
+		{Name: "svc_errors", Help: "svc_errors", Type: "counter", Labels: []string{"error_type"}},
+		{Name: "svc_sizes", Help: "svc_sizes", Type: "histogram", Buckets: []float64{1, 2}},
+	}, metrics.Catalogue().Metrics)
+}
+
+func TestCatalogueUnits(t *testing.T) {
+	catalogue, err := ParseCatalogue([]byte(`metrics: [{name: heap, type: gauge, unit: bytes}, {name: query, type: timer, unit: milliseconds},
+  {name: calc, type: timer, unit: seconds}, {name: wait, type: summary, unit: seconds}]`))
+	assert.NoError(t, err)
+	clock := NewFakeClock(testStart)
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc", Catalogue: catalogue, Strict: true,
+		ErrorPolicy: IgnoreOnError, Clock: clock})
+
+	metrics.Gauge("heap").SetValue(1024)
+	timedFor(clock, 1500*time.Millisecond, metrics.Timer("query"))
+	timedFor(clock, time.Second, metrics.Timer("calc"))
+	metrics.Summary("wait").ObserveDuration(2 * time.Second)
+	metrics.WithUnit(Milliseconds).Summary("wait").ObserveDuration(2 * time.Second) // given in code, so not declared
+
+	assert.ElementsMatch(t, []string{"svc_heap_bytes", "svc_query_milliseconds", "svc_calc", "svc_wait_seconds", "promenade_undeclared_metric_total"},
+		metrics.TestHelper().MetricNames())
+
+	query, err := metrics.TestHelper().SummarySnapshot("query_milliseconds")
+	assert.NoError(t, err)
+	assert.Equal(t, 1500.0, query.Sum)
+	assert.Equal(t, "query_milliseconds", catalogue.Metrics[1].NameWithUnit())
+	assert.Equal(t, "calc", catalogue.Metrics[2].NameWithUnit())
+}
@@ -0,0 +1,26 @@
+package api
+
+import "time"
+
+// Clock tells the time for timers and SLO burn rates, so tests can control it, e.g. with a FakeClock
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// clockOf returns the clock metrics use, or the system clock for implementations without one
+func clockOf(metrics PrometheusMetrics) Clock {
+	if clocked, ok := metrics.(interface{ getClock() Clock }); ok {
+		return clocked.getClock()
+	}
+	return systemClock{}
+}
+
+func (p *PrometheusMetricsImpl) getClock() Clock {
+	return p.clock
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type contextKey int
+
+const (
+	metricsKey contextKey = iota
+	exemplarKey
+	labelsKey
+)
+
+// contextLabel is one of a linked list, so adding a label doesn't copy those already in the context
+type contextLabel struct {
+	name   string
+	value  string
+	parent *contextLabel
+}
+
+// WithMetrics returns a context carrying metrics, for FromContext and the Ctx helpers
+func WithMetrics(ctx context.Context, metrics PrometheusMetrics) context.Context {
+	return context.WithValue(ctx, metricsKey, metrics)
+}
+
+// FromContext returns the metrics given to WithMetrics, or no-op metrics if there are none
+func FromContext(ctx context.Context) PrometheusMetrics {
+	if metrics, ok := ctx.Value(metricsKey).(PrometheusMetrics); ok {
+		return metrics
+	}
+	return noopMetrics{}
+}
+
+// WithExemplar returns a context carrying the trace that observations made with it should link to, e.g. set by
+// tracing middleware
+func WithExemplar(ctx context.Context, exemplar Exemplar) context.Context {
+	return context.WithValue(ctx, exemplarKey, exemplar)
+}
+
+// ExemplarFromContext returns the exemplar given to WithExemplar, or an empty one, which is never attached
+func ExemplarFromContext(ctx context.Context) Exemplar {
+	exemplar, _ := ctx.Value(exemplarKey).(Exemplar)
+	return exemplar
+}
+
+// WithLabel returns a context carrying a label value, e.g. the tenant or route, for the Ctx helpers of metrics
+// having that label. A later value for the same name replaces the earlier one.
+func WithLabel(ctx context.Context, name string, value string) context.Context {
+	parent, _ := ctx.Value(labelsKey).(*contextLabel)
+	return context.WithValue(ctx, labelsKey, &contextLabel{name: name, value: value, parent: parent})
+}
+
+// LabelFromContext returns the value given to WithLabel for name, or "" if there is none
+func LabelFromContext(ctx context.Context, name string) string {
+	for label, _ := ctx.Value(labelsKey).(*contextLabel); label != nil; label = label.parent {
+		if label.name == name {
+			return label.value
+		}
+	}
+	return ""
+}
+
+func labelValuesFromContext(ctx context.Context, labelNames []string) []string {
+	labelValues := make([]string, len(labelNames))
+	for i, each := range labelNames {
+		labelValues[i] = LabelFromContext(ctx, each)
+	}
+	return labelValues
+}
+
+// TimerCtx starts a stopwatch from the context's metrics, with the named labels taking their values from the
+// context. Stop links to the context's exemplar unless given another.
+func TimerCtx(ctx context.Context, name string, labelNames ...string) *Stopwatch {
+	metrics := FromContext(ctx)
+
+	var stopwatch *Stopwatch
+	if len(labelNames) == 0 {
+		stopwatch = metrics.Timer(name)
+	} else {
+		stopwatch = metrics.TimerWithLabels(name, labelNames, labelValuesFromContext(ctx, labelNames)...)
+	}
+
+	if stopwatch != nil {
+		stopwatch.exemplar = ExemplarFromContext(ctx)
+	}
+	return stopwatch
+}
+
+// IncCtx adds one to a counter from the context's metrics, with the named labels taking their values from the
+// context, linked to the context's exemplar
+func IncCtx(ctx context.Context, name string, labelNames ...string) {
+	metrics := FromContext(ctx)
+	if len(labelNames) == 0 {
+		metrics.Counter(name).IncWithExemplar(ExemplarFromContext(ctx))
+		return
+	}
+	metrics.CounterWithLabels(name, labelNames).IncLabelWithExemplar(ExemplarFromContext(ctx), labelValuesFromContext(ctx, labelNames)...)
+}
+
+// ErrorCtx counts an error in the context's metrics, linked to the context's exemplar. The errors counter is only
+// labelled by error_type, so the named labels' values from the context are added to the exemplar instead.
+func ErrorCtx(ctx context.Context, name string, labelNames ...string) ErrorCounter {
+	exemplar := ExemplarFromContext(ctx)
+	if len(labelNames) > 0 && exemplar.valid() {
+		exemplar.extra = prometheus.Labels{}
+		for _, each := range labelNames {
+			exemplar.extra[strings.Map(legacyRune(false), each)] = LabelFromContext(ctx, each)
+		}
+	}
+	return errorWithExemplarOf(FromContext(ctx), name, exemplar)
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContextDefaultsToNoop(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, NewNoopMetrics(), FromContext(ctx))
+
+	IncCtx(ctx, "requests", "tenant") // no metrics, but no panic either
+	ErrorCtx(ctx, "failed")
+	assert.Equal(t, time.Duration(0), TimerCtx(ctx, "calc").Stop())
+}
+
+func TestContextHelpers(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), Clock: clock})
+
+	ctx := WithMetrics(context.Background(), &metrics)
+	ctx = WithExemplar(ctx, Exemplar{TraceID: "abc"})
+	ctx = WithLabel(ctx, "tenant", "acme")
+	ctx = WithLabel(ctx, "route", "/old")
+	ctx = WithLabel(ctx, "route", "/home")
+
+	assert.Equal(t, "/home", LabelFromContext(ctx, "route"))
+	assert.Equal(t, "", LabelFromContext(ctx, "region"))
+
+	IncCtx(ctx, "requests")
+	IncCtx(ctx, "tenant_requests", "tenant", "route")
+	IncCtx(ctx, "region_requests", "region")
+	ErrorCtx(ctx, "failed")
+	assert.Equal(t, 2*time.Second, timedFor(clock, 2*time.Second, TimerCtx(ctx, "calc")))
+	timedFor(clock, 2*time.Second, TimerCtx(ctx, "fetch", "tenant", "route"))
+
+	helper := metrics.TestHelper()
+	assertValue(t, 1)(helper.CounterValue("tenant_requests", "acme", "/home"))
+	assertValue(t, 1)(helper.CounterValue("region_requests", ""))
+	assertValue(t, 1)(helper.CounterValue("errors", "failed"))
+
+	_, series, err := helper.Series("requests")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"trace_id": "abc"}, labelsOf(series.GetCounter().GetExemplar().GetLabel()))
+
+	summary, err := helper.SummarySnapshot("fetch", "acme", "/home")
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, summary.Sum)
+}
+
+func TestErrorCtx(t *testing.T) {
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry()})
+	other := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "other"})
+
+	ctx := WithMetrics(context.Background(), NewFanoutMetrics(&metrics, &other))
+	ctx = WithExemplar(ctx, Exemplar{TraceID: "abc"})
+	ctx = WithLabel(ctx, "tenant", "acme")
+
+	ErrorCtx(ctx, "failed")
+	ErrorCtx(ctx, "declined", "tenant", "route")
+
+	for _, each := range []*PrometheusMetricsImpl{&metrics, &other} {
+		_, series, err := each.TestHelper().Series("errors", "failed")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"trace_id": "abc"}, labelsOf(series.GetCounter().GetExemplar().GetLabel()))
+
+		_, series, err = each.TestHelper().Series("errors", "declined")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"trace_id": "abc", "tenant": "acme", "route": ""},
+			labelsOf(series.GetCounter().GetExemplar().GetLabel()))
+	}
+}
+
+func TestTimerWithLabels(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), Clock: clock})
+
+	timedFor(clock, time.Second, metrics.TimerWithLabels("fetch", []string{"source", "cache"}, "db", "miss"))
+	timedFor(clock, time.Second, metrics.WithUnit(Milliseconds).TimerWithLabels("wait", []string{"queue"}, "jobs"))
+
+	summary, err := metrics.TestHelper().SummarySnapshot("fetch", "db", "miss")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, summary.Sum)
+
+	summary, err = metrics.TestHelper().SummarySnapshot("wait_milliseconds", "jobs")
+	assert.NoError(t, err)
+	assert.Equal(t, 1000.0, summary.Sum)
+}
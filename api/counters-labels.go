@@ -1,13 +1,11 @@
 package api
 
-import "github.com/prometheus/client_golang/prometheus"
-
 type LabelledCounterFacade struct {
-	promMetric *prometheus.CounterVec
+	metric CounterVecInstrument
 }
 
-func (p *PrometheusMetricsImpl) buildLabelledCounter(builder MetricBuilder, name string, optionalDesc []string) LabelledCounterFacade {
-	return p.getOrAdd(name, TypeCounterLabels, builder, optionalDesc).(LabelledCounterFacade)
+func (p *PrometheusMetricsImpl) buildLabelledCounter(builder MetricBuilder, name string, unit string, labelNames []string, optionalDesc []string) LabelledCounterFacade {
+	return p.getOrAdd(name, TypeCounterLabels, unit, labelNames, builder, optionalDesc).(LabelledCounterFacade)
 }
 
 func (p *PrometheusMetricsImpl) CounterWithLabel(name string, labelName string, optionalDesc ...string) LabelledCounterFacade {
@@ -15,25 +13,50 @@ func (p *PrometheusMetricsImpl) CounterWithLabel(name string, labelName string,
 }
 
 func (p *PrometheusMetricsImpl) CounterWithLabels(name string, labelNames []string, optionalDesc ...string) LabelledCounterFacade {
+	return p.labelledCounter(name, Unit{}, labelNames, optionalDesc)
+}
+
+func (p *PrometheusMetricsImpl) labelledCounter(name string, unit Unit, labelNames []string, optionalDesc []string) LabelledCounterFacade {
+	unit = p.declaredUnit(name, unit)
+	labelNames = p.normaliseLabelNames(labelNames)
 	return p.buildLabelledCounter(func(p *PrometheusMetricsImpl, fullMetricName string, fullDescription string) interface{} {
-		internal := prometheus.NewCounterVec(prometheus.CounterOpts{Name: fullMetricName, Help: fullDescription}, labelNames)
-		p.Register(internal)
-		return LabelledCounterFacade{promMetric: internal}
-	}, name, optionalDesc)
+		return LabelledCounterFacade{metric: p.backend.NewCounterVec(InstrumentOpts{Name: fullMetricName, Help: fullDescription, LabelNames: labelNames, Unit: unit.exposed()})}
+	}, unit.suffix(name), unit.expected(), labelNames, optionalDesc)
 }
 
 func (f LabelledCounterFacade) IncLabel(labelValues ...string) {
-	f.promMetric.WithLabelValues(labelValues...).Inc()
+	f.series(labelValues).Inc()
+}
+
+// series returns the counter with the label values, or discards for a zero-value facade, as no-op metrics return.
+// The values are copied, so the caller's variadic slice doesn't escape and discarding doesn't allocate.
+func (f LabelledCounterFacade) series(labelValues []string) CounterInstrument {
+	if f.metric == nil {
+		return noopInstrument{}
+	}
+	values := make([]string, len(labelValues))
+	copy(values, labelValues)
+	return f.metric.WithLabelValues(values...)
 }
 
 type IncByValue struct {
-	counter prometheus.Counter
+	counter CounterInstrument
 }
 
 func (f LabelledCounterFacade) IncLabelBy(labelValues ...string) IncByValue {
-	return IncByValue{counter: f.promMetric.WithLabelValues(labelValues...)}
+	return IncByValue{counter: f.series(labelValues)}
 }
 
 func (f IncByValue) Value(inc float64) {
 	f.counter.Add(inc)
 }
+
+// IncLabelWithExemplar adds one to the series with the label values, linked to the trace in exemplar
+func (f LabelledCounterFacade) IncLabelWithExemplar(exemplar Exemplar, labelValues ...string) {
+	addWithExemplar(f.series(labelValues), 1, exemplar)
+}
+
+// ValueWithExemplar adds inc, linked to the trace in exemplar
+func (f IncByValue) ValueWithExemplar(inc float64, exemplar Exemplar) {
+	addWithExemplar(f.counter, inc, exemplar)
+}
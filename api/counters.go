@@ -1,27 +1,46 @@
 package api
 
-import "github.com/prometheus/client_golang/prometheus"
+import "time"
 
 type CounterFacade struct {
-	promMetric prometheus.Counter
+	metric CounterInstrument
+	unit   Unit
 }
 
-func (p *PrometheusMetricsImpl) buildCounter(builder MetricBuilder, name string, optionalDesc []string) CounterFacade {
-	return p.getOrAdd(name, TypeCounter, builder, optionalDesc).(CounterFacade)
+func (p *PrometheusMetricsImpl) buildCounter(builder MetricBuilder, name string, unit string, optionalDesc []string) CounterFacade {
+	return p.getOrAdd(name, TypeCounter, unit, nil, builder, optionalDesc).(CounterFacade)
 }
 
 func (p *PrometheusMetricsImpl) Counter(name string, optionalDesc ...string) CounterFacade {
+	return p.counter(name, Unit{}, optionalDesc)
+}
+
+func (p *PrometheusMetricsImpl) counter(name string, unit Unit, optionalDesc []string) CounterFacade {
+	unit = p.declaredUnit(name, unit)
 	return p.buildCounter(func(p *PrometheusMetricsImpl, fullMetricName string, fullDescription string) interface{} {
-		internal := prometheus.NewCounter(prometheus.CounterOpts{Name: fullMetricName, Help: fullDescription})
-		p.Register(internal)
-		return CounterFacade{promMetric: internal}
-	}, name, optionalDesc)
+		return CounterFacade{metric: p.backend.NewCounter(InstrumentOpts{Name: fullMetricName, Help: fullDescription, Unit: unit.exposed()}), unit: unit}
+	}, unit.suffix(name), unit.expected(), optionalDesc)
 }
 
 func (f CounterFacade) Inc() {
-	f.promMetric.Inc()
+	f.metric.Inc()
 }
 
 func (f CounterFacade) IncBy(inc float64) {
-	f.promMetric.Add(inc)
+	f.metric.Add(inc)
+}
+
+// IncByDuration adds d in the counter's unit of time, or seconds
+func (f CounterFacade) IncByDuration(d time.Duration) {
+	f.metric.Add(f.unit.fromDuration(d))
+}
+
+// IncWithExemplar adds one, linked to the trace in exemplar
+func (f CounterFacade) IncWithExemplar(exemplar Exemplar) {
+	addWithExemplar(f.metric, 1, exemplar)
+}
+
+// IncByWithExemplar adds inc, linked to the trace in exemplar
+func (f CounterFacade) IncByWithExemplar(inc float64, exemplar Exemplar) {
+	addWithExemplar(f.metric, inc, exemplar)
 }
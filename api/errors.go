@@ -1,30 +1,44 @@
 package api
 
-import "github.com/prometheus/client_golang/prometheus"
-
 type ErrorCounter struct {
-	promMetric *prometheus.CounterVec
+	metric CounterVecInstrument
 }
 
 func (p *PrometheusMetricsImpl) Error(name string) ErrorCounter {
-	return p.incrementError(name)
+	return p.errorWithExemplar(name, Exemplar{})
+}
+
+// errorWithExemplarOf counts the error linked to the exemplar, or without it in metrics that can't attach one
+func errorWithExemplarOf(metrics PrometheusMetrics, name string, exemplar Exemplar) ErrorCounter {
+	if linked, ok := metrics.(interface {
+		errorWithExemplar(string, Exemplar) ErrorCounter
+	}); ok {
+		return linked.errorWithExemplar(name, exemplar)
+	}
+	return metrics.Error(name)
 }
 
-func (p *PrometheusMetricsImpl) incrementError(name string) ErrorCounter {
+func (p *PrometheusMetricsImpl) errorWithExemplar(name string, exemplar Exemplar) ErrorCounter {
 	var counter = p.getErrorCounter()
-	counter.With(prometheus.Labels{"error_type": name}).Inc()
-	return ErrorCounter{promMetric: counter}
+	addWithExemplar(counter.WithLabelValues(name), 1, exemplar)
+	return ErrorCounter{metric: counter}
 }
 
-// FIXME @Synchronized
-func (p *PrometheusMetricsImpl) getErrorCounter() *prometheus.CounterVec {
+func (p *PrometheusMetricsImpl) getErrorCounter() CounterVecInstrument {
+	p.registrations.RLock()
+	counter := p.errorCounter
+	p.registrations.RUnlock()
+	if counter != nil {
+		return counter
+	}
+
+	p.registrations.Lock()
+	defer p.registrations.Unlock()
 	if p.errorCounter == nil {
 		var adjustedName = p.metricNamePrefix + "errors"
 		var description = adjustedName
 
-		internal := prometheus.NewCounterVec(prometheus.CounterOpts{Name: adjustedName, Help: description}, []string{"error_type"})
-		p.MustRegister(internal)
-		p.errorCounter = internal
+		p.errorCounter = p.backend.NewCounterVec(InstrumentOpts{Name: adjustedName, Help: description, LabelNames: []string{"error_type"}})
 		p.errorCounterName = adjustedName
 	}
 	return p.errorCounter
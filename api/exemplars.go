@@ -0,0 +1,69 @@
+package api
+
+import (
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Exemplar links an observation to the trace it was made in, so dashboards can jump from a spike to an example
+// request. Prometheus only keeps exemplars on counters and histograms, and only serves them as OpenMetrics,
+// e.g. from Handler. Summaries, and so the default Prometheus timers, drop them.
+type Exemplar struct {
+	TraceID string
+	SpanID  string
+
+	extra prometheus.Labels // e.g. contextual labels, where the metric has no such label
+}
+
+func (e Exemplar) labels() prometheus.Labels {
+	labels := prometheus.Labels{}
+	for name, value := range e.extra {
+		labels[name] = value
+	}
+	if e.TraceID != "" {
+		labels["trace_id"] = e.TraceID
+	}
+	if e.SpanID != "" {
+		labels["span_id"] = e.SpanID
+	}
+	return labels
+}
+
+// valid is false for an empty exemplar, or one too long for Prometheus, which would otherwise panic
+func (e Exemplar) valid() bool {
+	if e.TraceID == "" && e.SpanID == "" {
+		return false
+	}
+	runes := 0
+	for name, value := range e.labels() {
+		runes += utf8.RuneCountInString(name) + utf8.RuneCountInString(value)
+	}
+	return runes <= prometheus.ExemplarMaxRunes
+}
+
+// addWithExemplar adds to the counter, attaching the exemplar where the instrument can hold one
+func addWithExemplar(counter CounterInstrument, value float64, exemplar Exemplar) {
+	if adder, ok := counter.(prometheus.ExemplarAdder); ok && exemplar.valid() {
+		adder.AddWithExemplar(value, exemplar.labels())
+		return
+	}
+	counter.Add(value)
+}
+
+// observeWithExemplar observes the value, attaching the exemplar where the instrument can hold one
+func observeWithExemplar(observer ObserverInstrument, value float64, exemplar Exemplar) {
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && exemplar.valid() {
+		exemplarObserver.ObserveWithExemplar(value, exemplar.labels())
+		return
+	}
+	observer.Observe(value)
+}
+
+// firstExemplar returns the optional exemplar passed to a timer's stop function, or an empty one
+func firstExemplar(exemplars []Exemplar) Exemplar {
+	if len(exemplars) > 0 {
+		return exemplars[0]
+	}
+	return Exemplar{}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	clientmodel "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+var testExemplar = Exemplar{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"}
+
+func TestExemplars(t *testing.T) {
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc"})
+
+	metrics.Counter("requests_total").IncWithExemplar(testExemplar)
+	metrics.CounterWithLabel("bytes_total", "route").IncLabelBy("/home").ValueWithExemplar(512, testExemplar)
+	metrics.CounterWithLabel("errors_total", "route").IncLabelWithExemplar(Exemplar{TraceID: "abc"}, "/home")
+	metrics.HistogramForResponseTime("latency").UpdateWithExemplar(0.3, testExemplar)
+	metrics.WithUnit(Milliseconds).HistogramForResponseTime("db_latency").UpdateDurationWithExemplar(250*time.Millisecond, testExemplar)
+	metrics.Timer("timer").Stop(testExemplar)
+
+	helper := metrics.TestHelper()
+
+	_, series, err := helper.Series("requests_total")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, series.GetCounter().GetExemplar().GetValue())
+	assert.Equal(t, map[string]string{"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736", "span_id": "00f067aa0ba902b7"},
+		labelsOf(series.GetCounter().GetExemplar().GetLabel()))
+
+	_, series, err = helper.Series("bytes_total", "/home")
+	assert.NoError(t, err)
+	assert.Equal(t, 512.0, series.GetCounter().GetExemplar().GetValue())
+
+	_, series, err = helper.Series("errors_total", "/home")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"trace_id": "abc"}, labelsOf(series.GetCounter().GetExemplar().GetLabel()))
+
+	_, series, err = helper.Series("latency")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.3, series.GetHistogram().GetBucket()[6].GetExemplar().GetValue()) // the 0.5 bucket
+
+	_, series, err = helper.Series("db_latency_milliseconds")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, countExemplars(series.GetHistogram().GetBucket()))
+
+	snapshot, err := helper.SummarySnapshot("timer")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), snapshot.Count) // observed, but summaries can't keep the exemplar
+}
+
+func TestExemplarsDroppedWhenInvalid(t *testing.T) {
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry()})
+
+	metrics.Counter("empty").IncByWithExemplar(2, Exemplar{})
+	metrics.Counter("long").IncByWithExemplar(3, Exemplar{TraceID: strings.Repeat("a", 200)})
+
+	helper := metrics.TestHelper()
+	for name, expected := range map[string]float64{"empty": 2, "long": 3} {
+		_, series, err := helper.Series(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, series.GetCounter().GetValue())
+		assert.Nil(t, series.GetCounter().GetExemplar(), name)
+	}
+}
+
+func TestFanoutExemplars(t *testing.T) {
+	primary := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry()})
+	recording := NewRecordingBackend()
+	secondary := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), Backend: recording})
+	fanout := NewFanoutMetrics(&primary, &secondary)
+
+	fanout.Counter("requests").IncWithExemplar(testExemplar)
+	fanout.Histogram("sizes", []float64{1, 10}).UpdateWithExemplar(5, testExemplar)
+
+	_, series, err := primary.TestHelper().Series("requests")
+	assert.NoError(t, err)
+	assert.NotNil(t, series.GetCounter().GetExemplar())
+
+	_, series, err = primary.TestHelper().Series("sizes")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, countExemplars(series.GetHistogram().GetBucket()))
+
+	assert.Equal(t, 1.0, recording.CounterValue("requests")) // no exemplars, but still counted
+	assert.Equal(t, uint64(1), recording.HistogramCount("sizes"))
+}
+
+func TestHandlerNegotiatesOpenMetrics(t *testing.T) {
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc"})
+	metrics.Counter("requests_total").IncWithExemplar(Exemplar{TraceID: "abc"})
+
+	server := httptest.NewServer(metrics.Handler())
+	defer server.Close()
+
+	body := get(t, server.URL, "application/openmetrics-text; version=1.0.0")
+	assert.Contains(t, body, `svc_requests_total 1.0 # {trace_id="abc"} 1.0`)
+	assert.Contains(t, body, "# EOF")
+
+	body = get(t, server.URL, "")
+	assert.Contains(t, body, "svc_requests_total 1\n")
+	assert.NotContains(t, body, "trace_id")
+
+	metrics.TestHelper().Clear()
+	assert.NotContains(t, get(t, server.URL, ""), "svc_requests_total")
+}
+
+func get(t *testing.T, url string, accept string) string {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	assert.NoError(t, err)
+	if accept != "" {
+		request.Header.Set("Accept", accept)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	assert.NoError(t, err)
+	defer response.Body.Close()
+
+	var body strings.Builder
+	_, err = io.Copy(&body, response.Body)
+	assert.NoError(t, err)
+	return body.String()
+}
+
+func countExemplars(buckets []*clientmodel.Bucket) int {
+	count := 0
+	for _, each := range buckets {
+		if each.GetExemplar() != nil {
+			count++
+		}
+	}
+	return count
+}
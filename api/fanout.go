@@ -0,0 +1,370 @@
+package api
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// fanoutMetrics dispatches every call to each of its children, e.g. an old and new registry during a migration
+type fanoutMetrics struct {
+	children []PrometheusMetrics
+	types    *PrometheusMetricsImpl // only tracks the type used for each name
+	unit     Unit
+	slos     *sloRegistry // shared with WithUnit, as types is
+}
+
+// NewFanoutMetrics returns metrics that write to primary and all the others. Reusing a name for a different
+// type of metric is handled by the primary's ErrorPolicy before any child is touched, returning a facade that
+// discards everything if it doesn't panic, so the children can't get out of step. TestHelper() is the primary's.
+func NewFanoutMetrics(primary PrometheusMetrics, others ...PrometheusMetrics) PrometheusMetrics {
+	types := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), Backend: NewNoopBackend(), ErrorPolicy: errorPolicyOf(primary)})
+	return &fanoutMetrics{children: append([]PrometheusMetrics{primary}, others...), types: &types, slos: &sloRegistry{}}
+}
+
+// checkType keys on the name as the children expose it, i.e. with any unit, returning false if it's already
+// used for another type and the ErrorPolicy didn't panic
+func (f *fanoutMetrics) checkType(name string, metricType int) bool {
+	return f.checkTypeWithUnit(name, f.unit, metricType)
+}
+
+// checkTimerType applies the seconds timers imply, which isn't added to the name
+func (f *fanoutMetrics) checkTimerType(name string, metricType int) bool {
+	return f.checkTypeWithUnit(name, f.unit.orSeconds(), metricType)
+}
+
+// checkTypeWithUnit records true for each name, which handleError's discarding builds turn into false
+func (f *fanoutMetrics) checkTypeWithUnit(name string, unit Unit, metricType int) bool {
+	return f.types.getOrAdd(unit.suffix(name), metricType, "", nil, func(p *PrometheusMetricsImpl, _ string, _ string) interface{} {
+		return p != discarding
+	}, nil).(bool)
+}
+
+func (f *fanoutMetrics) Register(metric prometheus.Collector) error {
+	var firstErr error
+	for _, each := range f.children {
+		if err := each.Register(metric); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (f *fanoutMetrics) MustRegister(metric prometheus.Collector) {
+	for _, each := range f.children {
+		each.MustRegister(metric)
+	}
+}
+
+func (f *fanoutMetrics) TestHelper() *TestHelper {
+	return f.children[0].TestHelper()
+}
+
+func (f *fanoutMetrics) Counter(name string, optionalDesc ...string) CounterFacade {
+	if !f.checkType(name, TypeCounter) {
+		return noopMetrics{}.Counter(name)
+	}
+	counters := make(fanoutCounter, len(f.children))
+	for i, each := range f.children {
+		counters[i] = each.Counter(name, optionalDesc...).metric
+	}
+	return CounterFacade{metric: counters}
+}
+
+func (f *fanoutMetrics) CounterWithLabel(name string, labelName string, optionalDesc ...string) LabelledCounterFacade {
+	return f.CounterWithLabels(name, []string{labelName}, optionalDesc...)
+}
+
+// CounterWithLabels, like the other labelled metrics, skips children returning a zero-value facade, as no-op metrics do
+func (f *fanoutMetrics) CounterWithLabels(name string, labelNames []string, optionalDesc ...string) LabelledCounterFacade {
+	if !f.checkType(name, TypeCounterLabels) {
+		return noopMetrics{}.CounterWithLabels(name, labelNames)
+	}
+	counters := make(fanoutCounterVec, 0, len(f.children))
+	for _, each := range f.children {
+		if vec := each.CounterWithLabels(name, labelNames, optionalDesc...).metric; vec != nil {
+			counters = append(counters, vec)
+		}
+	}
+	return LabelledCounterFacade{metric: counters}
+}
+
+func (f *fanoutMetrics) Error(name string) ErrorCounter {
+	return f.errorWithExemplar(name, Exemplar{})
+}
+
+func (f *fanoutMetrics) errorWithExemplar(name string, exemplar Exemplar) ErrorCounter {
+	counters := make(fanoutCounterVec, len(f.children))
+	for i, each := range f.children {
+		counters[i] = errorWithExemplarOf(each, name, exemplar).metric
+	}
+	return ErrorCounter{metric: counters}
+}
+
+func (f *fanoutMetrics) Gauge(name string, optionalDesc ...string) GaugeFacade {
+	if !f.checkType(name, TypeGauge) {
+		return noopMetrics{}.Gauge(name)
+	}
+	gauges := make(fanoutGauge, len(f.children))
+	for i, each := range f.children {
+		gauges[i] = each.Gauge(name, optionalDesc...).metric
+	}
+	return GaugeFacade{metric: gauges}
+}
+
+func (f *fanoutMetrics) GaugeWithLabel(name string, labelName string, optionalDesc ...string) LabelledGaugeFacade {
+	return f.GaugeWithLabels(name, []string{labelName}, optionalDesc...)
+}
+
+func (f *fanoutMetrics) GaugeWithLabels(name string, labelNames []string, optionalDesc ...string) LabelledGaugeFacade {
+	if !f.checkType(name, TypeGaugeLabels) {
+		return noopMetrics{}.GaugeWithLabels(name, labelNames)
+	}
+	gauges := make(fanoutGaugeVec, 0, len(f.children))
+	for _, each := range f.children {
+		if vec := each.GaugeWithLabels(name, labelNames, optionalDesc...).metric; vec != nil {
+			gauges = append(gauges, vec)
+		}
+	}
+	return LabelledGaugeFacade{metric: gauges}
+}
+
+func (f *fanoutMetrics) Histogram(name string, buckets []float64, optionalDesc ...string) HistogramFacade {
+	if !f.checkType(name, TypeHistogram) {
+		return noopMetrics{}.Histogram(name, buckets)
+	}
+	observers := make(fanoutObserver, len(f.children))
+	for i, each := range f.children {
+		observers[i] = each.Histogram(name, buckets, optionalDesc...).metric
+	}
+	return HistogramFacade{metric: observers}
+}
+
+func (f *fanoutMetrics) HistogramForResponseTime(name string, optionalDesc ...string) HistogramFacade {
+	if !f.checkType(name, TypeHistogram) {
+		return noopMetrics{}.HistogramForResponseTime(name)
+	}
+	observers := make(fanoutObserver, len(f.children))
+	for i, each := range f.children {
+		observers[i] = each.HistogramForResponseTime(name, optionalDesc...).metric
+	}
+	return HistogramFacade{metric: observers}
+}
+
+func (f *fanoutMetrics) Summary(name string, optionalDesc ...string) SummaryFacade {
+	if !f.checkType(name, TypeSummary) {
+		return noopMetrics{}.Summary(name)
+	}
+	observers := make(fanoutObserver, len(f.children))
+	for i, each := range f.children {
+		observers[i] = each.Summary(name, optionalDesc...).metric
+	}
+	return SummaryFacade{metric: observers}
+}
+
+func (f *fanoutMetrics) SummaryWithLabel(name string, labelName string, optionalDesc ...string) LabelledSummaryFacade {
+	return f.SummaryWithLabels(name, []string{labelName}, optionalDesc...)
+}
+
+func (f *fanoutMetrics) SummaryWithLabels(name string, labelNames []string, optionalDesc ...string) LabelledSummaryFacade {
+	if !f.checkType(name, TypeSummaryLabels) {
+		return noopMetrics{}.SummaryWithLabels(name, labelNames)
+	}
+	observers := make(fanoutObserverVec, 0, len(f.children))
+	for _, each := range f.children {
+		if vec := each.SummaryWithLabels(name, labelNames, optionalDesc...).metric; vec != nil {
+			observers = append(observers, vec)
+		}
+	}
+	return LabelledSummaryFacade{metric: observers}
+}
+
+// Timer starts a stopwatch in every child, and returns one timing with the primary's, observing in all of them
+func (f *fanoutMetrics) Timer(Name string) *Stopwatch {
+	if !f.checkTimerType(Name, TypeSummary) {
+		return noopMetrics{}.Timer(Name)
+	}
+	stopwatches := make([]*Stopwatch, len(f.children))
+	for i, each := range f.children {
+		stopwatches[i] = each.Timer(Name)
+	}
+	return fanoutStopwatch(stopwatches)
+}
+
+func (f *fanoutMetrics) TimerWithLabel(Name string, labelName string, labelValue string) *Stopwatch {
+	return f.TimerWithLabels(Name, []string{labelName}, labelValue)
+}
+
+func (f *fanoutMetrics) TimerWithLabels(Name string, labelNames []string, labelValues ...string) *Stopwatch {
+	if !f.checkTimerType(Name, TypeSummaryLabels) {
+		return noopMetrics{}.TimerWithLabels(Name, labelNames, labelValues...)
+	}
+	stopwatches := make([]*Stopwatch, len(f.children))
+	for i, each := range f.children {
+		stopwatches[i] = each.TimerWithLabels(Name, labelNames, labelValues...)
+	}
+	return fanoutStopwatch(stopwatches)
+}
+
+// SLO tracks burn rates once, writing its counters and gauges to every child
+func (f *fanoutMetrics) SLO(name string, target float64, latencyThreshold time.Duration, windows ...time.Duration) *SLO {
+	return f.slos.get(name, func() *SLO { return newSLO(f, f.getClock(), name, target, latencyThreshold, windows) })
+}
+
+// getClock is the primary's, which times the stopwatches too
+func (f *fanoutMetrics) getClock() Clock {
+	return clockOf(f.children[0])
+}
+
+// WithUnit applies unit in every child, still checking types across all of them
+func (f *fanoutMetrics) WithUnit(unit Unit) PrometheusMetrics {
+	children := make([]PrometheusMetrics, len(f.children))
+	for i, each := range f.children {
+		children[i] = each.WithUnit(unit)
+	}
+	return &fanoutMetrics{children: children, types: f.types, unit: unit, slos: f.slos}
+}
+
+func (f *fanoutMetrics) getErrorPolicy() ErrorPolicy {
+	return f.types.errorPolicy
+}
+
+// fanoutStopwatch times with the first child's stopwatch, skipping any nil ones from no-op children
+func fanoutStopwatch(stopwatches []*Stopwatch) *Stopwatch {
+	var children []*Stopwatch
+	for _, each := range stopwatches {
+		if each != nil {
+			children = append(children, each)
+		}
+	}
+	if len(children) == 0 {
+		return nil
+	}
+
+	return &Stopwatch{clock: children[0].clock,
+		start: children[0].start,
+		observe: func(seconds float64, exemplar Exemplar) {
+			for _, each := range children {
+				each.observe(seconds, exemplar)
+			}
+		},
+		lap: func(label string, seconds float64) {
+			for _, each := range children {
+				each.lap(label, seconds)
+			}
+		},
+	}
+}
+
+type fanoutCounter []CounterInstrument
+
+func (c fanoutCounter) Inc() {
+	for _, each := range c {
+		each.Inc()
+	}
+}
+
+func (c fanoutCounter) Add(inc float64) {
+	for _, each := range c {
+		each.Add(inc)
+	}
+}
+
+func (c fanoutCounter) AddWithExemplar(inc float64, exemplar prometheus.Labels) {
+	for _, each := range c {
+		if adder, ok := each.(prometheus.ExemplarAdder); ok {
+			adder.AddWithExemplar(inc, exemplar)
+		} else {
+			each.Add(inc)
+		}
+	}
+}
+
+type fanoutCounterVec []CounterVecInstrument
+
+func (v fanoutCounterVec) WithLabelValues(labelValues ...string) CounterInstrument {
+	counters := make(fanoutCounter, len(v))
+	for i, each := range v {
+		counters[i] = each.WithLabelValues(labelValues...)
+	}
+	return counters
+}
+
+type fanoutGauge []GaugeInstrument
+
+func (g fanoutGauge) Set(value float64) {
+	for _, each := range g {
+		each.Set(value)
+	}
+}
+
+func (g fanoutGauge) Inc() {
+	for _, each := range g {
+		each.Inc()
+	}
+}
+
+func (g fanoutGauge) Dec() {
+	for _, each := range g {
+		each.Dec()
+	}
+}
+
+func (g fanoutGauge) Add(inc float64) {
+	for _, each := range g {
+		each.Add(inc)
+	}
+}
+
+func (g fanoutGauge) Sub(dec float64) {
+	for _, each := range g {
+		each.Sub(dec)
+	}
+}
+
+type fanoutGaugeVec []GaugeVecInstrument
+
+func (v fanoutGaugeVec) WithLabelValues(labelValues ...string) GaugeInstrument {
+	gauges := make(fanoutGauge, len(v))
+	for i, each := range v {
+		gauges[i] = each.WithLabelValues(labelValues...)
+	}
+	return gauges
+}
+
+// beforeCollect returns true only if every child runs hook, as any other still needs the gauges kept up to date
+func (v fanoutGaugeVec) beforeCollect(hook func()) bool {
+	all := true
+	for _, each := range v {
+		all = LabelledGaugeFacade{metric: each}.beforeCollect(hook) && all
+	}
+	return all
+}
+
+type fanoutObserver []ObserverInstrument
+
+func (o fanoutObserver) Observe(value float64) {
+	for _, each := range o {
+		each.Observe(value)
+	}
+}
+
+func (o fanoutObserver) ObserveWithExemplar(value float64, exemplar prometheus.Labels) {
+	for _, each := range o {
+		if observer, ok := each.(prometheus.ExemplarObserver); ok {
+			observer.ObserveWithExemplar(value, exemplar)
+		} else {
+			each.Observe(value)
+		}
+	}
+}
+
+type fanoutObserverVec []ObserverVecInstrument
+
+func (v fanoutObserverVec) WithLabelValues(labelValues ...string) ObserverInstrument {
+	observers := make(fanoutObserver, len(v))
+	for i, each := range v {
+		observers[i] = each.WithLabelValues(labelValues...)
+	}
+	return observers
+}
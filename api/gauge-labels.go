@@ -1,13 +1,11 @@
 package api
 
-import "github.com/prometheus/client_golang/prometheus"
-
 type LabelledGaugeFacade struct {
-	promMetric *prometheus.GaugeVec
+	metric GaugeVecInstrument
 }
 
-func (p *PrometheusMetricsImpl) buildLabelledGauge(builder MetricBuilder, name string, optionalDesc []string) LabelledGaugeFacade {
-	return p.getOrAdd(name, TypeGaugeLabels, builder, optionalDesc).(LabelledGaugeFacade)
+func (p *PrometheusMetricsImpl) buildLabelledGauge(builder MetricBuilder, name string, unit string, labelNames []string, optionalDesc []string) LabelledGaugeFacade {
+	return p.getOrAdd(name, TypeGaugeLabels, unit, labelNames, builder, optionalDesc).(LabelledGaugeFacade)
 }
 
 func (p *PrometheusMetricsImpl) GaugeWithLabel(name string, labelName string, optionalDesc ...string) LabelledGaugeFacade {
@@ -15,43 +13,66 @@ func (p *PrometheusMetricsImpl) GaugeWithLabel(name string, labelName string, op
 }
 
 func (p *PrometheusMetricsImpl) GaugeWithLabels(name string, labelNames []string, optionalDesc ...string) LabelledGaugeFacade {
+	return p.labelledGauge(name, Unit{}, labelNames, optionalDesc)
+}
+
+func (p *PrometheusMetricsImpl) labelledGauge(name string, unit Unit, labelNames []string, optionalDesc []string) LabelledGaugeFacade {
+	unit = p.declaredUnit(name, unit)
+	labelNames = p.normaliseLabelNames(labelNames)
 	return p.buildLabelledGauge(func(p *PrometheusMetricsImpl, fullMetricName string, fullDescription string) interface{} {
-		internal := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: fullMetricName, Help: fullDescription}, labelNames)
-		p.Register(internal)
-		return LabelledGaugeFacade{promMetric: internal}
-	}, name, optionalDesc)
+		return LabelledGaugeFacade{metric: p.backend.NewGaugeVec(InstrumentOpts{Name: fullMetricName, Help: fullDescription, LabelNames: labelNames, Unit: unit.exposed()})}
+	}, unit.suffix(name), unit.expected(), labelNames, optionalDesc)
 }
 
 func (f LabelledGaugeFacade) IncLabels(labelValues ...string) {
-	f.promMetric.WithLabelValues(labelValues...).Inc()
+	f.series(labelValues).Inc()
 }
 
 func (f LabelledGaugeFacade) DecLabels(labelValues ...string) {
-	f.promMetric.WithLabelValues(labelValues...).Dec()
+	f.series(labelValues).Dec()
 }
 
 func (f LabelledGaugeFacade) IncLabelsBy(labelValues ...string) IncGaugeByValue {
-	return IncGaugeByValue{gauge: f.promMetric.WithLabelValues(labelValues...)}
+	return IncGaugeByValue{gauge: f.series(labelValues)}
 }
 
 func (f LabelledGaugeFacade) DecLabelsBy(labelValues ...string) DecGaugeByValue {
-	return DecGaugeByValue{gauge: f.promMetric.WithLabelValues(labelValues...)}
+	return DecGaugeByValue{gauge: f.series(labelValues)}
 }
 
 func (f LabelledGaugeFacade) SetLabels(labelValues ...string) SetGaugeByValue {
-	return SetGaugeByValue{gauge: f.promMetric.WithLabelValues(labelValues...)}
+	return SetGaugeByValue{gauge: f.series(labelValues)}
+}
+
+// beforeCollect runs hook before the gauges are scraped, for backends that support it, returning false for those
+// that don't, e.g. because they push
+func (f LabelledGaugeFacade) beforeCollect(hook func()) bool {
+	if hooked, ok := f.metric.(interface{ beforeCollect(func()) bool }); ok {
+		return hooked.beforeCollect(hook)
+	}
+	return false
+}
+
+// series returns the gauge with the label values, copied as for LabelledCounterFacade
+func (f LabelledGaugeFacade) series(labelValues []string) GaugeInstrument {
+	if f.metric == nil {
+		return noopInstrument{}
+	}
+	values := make([]string, len(labelValues))
+	copy(values, labelValues)
+	return f.metric.WithLabelValues(values...)
 }
 
 type IncGaugeByValue struct {
-	gauge prometheus.Gauge
+	gauge GaugeInstrument
 }
 
 type DecGaugeByValue struct {
-	gauge prometheus.Gauge
+	gauge GaugeInstrument
 }
 
 type SetGaugeByValue struct {
-	gauge prometheus.Gauge
+	gauge GaugeInstrument
 }
 
 func (f IncGaugeByValue) Value(inc float64) {
@@ -1,39 +1,48 @@
 package api
 
-import "github.com/prometheus/client_golang/prometheus"
+import "time"
 
 type GaugeFacade struct {
-	promMetric prometheus.Gauge
+	metric GaugeInstrument
+	unit   Unit
 }
 
-func (p *PrometheusMetricsImpl) buildGauge(builder MetricBuilder, name string, optionalDesc []string) GaugeFacade {
-	return p.getOrAdd(name, TypeGauge, builder, optionalDesc).(GaugeFacade)
+func (p *PrometheusMetricsImpl) buildGauge(builder MetricBuilder, name string, unit string, optionalDesc []string) GaugeFacade {
+	return p.getOrAdd(name, TypeGauge, unit, nil, builder, optionalDesc).(GaugeFacade)
 }
 
 func (p *PrometheusMetricsImpl) Gauge(name string, optionalDesc ...string) GaugeFacade {
+	return p.gauge(name, Unit{}, optionalDesc)
+}
+
+func (p *PrometheusMetricsImpl) gauge(name string, unit Unit, optionalDesc []string) GaugeFacade {
+	unit = p.declaredUnit(name, unit)
 	return p.buildGauge(func(p *PrometheusMetricsImpl, fullMetricName string, fullDescription string) interface{} {
-		internal := prometheus.NewGauge(prometheus.GaugeOpts{Name: fullMetricName, Help: fullDescription})
-		p.Register(internal)
-		return GaugeFacade{promMetric: internal}
-	}, name, optionalDesc)
+		return GaugeFacade{metric: p.backend.NewGauge(InstrumentOpts{Name: fullMetricName, Help: fullDescription, Unit: unit.exposed()}), unit: unit}
+	}, unit.suffix(name), unit.expected(), optionalDesc)
 }
 
 func (f GaugeFacade) SetValue(value float64) {
-	f.promMetric.Set(value)
+	f.metric.Set(value)
+}
+
+// SetDuration sets d in the gauge's unit of time, or seconds
+func (f GaugeFacade) SetDuration(d time.Duration) {
+	f.metric.Set(f.unit.fromDuration(d))
 }
 
 func (f GaugeFacade) Inc() {
-	f.promMetric.Inc()
+	f.metric.Inc()
 }
 
 func (f GaugeFacade) IncBy(inc float64) {
-	f.promMetric.Add(inc)
+	f.metric.Add(inc)
 }
 
 func (f GaugeFacade) Dec() {
-	f.promMetric.Dec()
+	f.metric.Dec()
 }
 
 func (f GaugeFacade) DecBy(dec float64) {
-	f.promMetric.Sub(dec)
+	f.metric.Sub(dec)
 }
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	clientmodel "github.com/prometheus/client_model/go"
+)
+
+// Handler serves every metric in the registry, as OpenMetrics to scrapers that ask for it, so exemplars and
+// units are included, otherwise as the classic text format. It follows TestHelper.Clear to the new registry.
+func (p *PrometheusMetricsImpl) Handler() http.Handler {
+	gatherer := prometheus.GathererFunc(func() ([]*clientmodel.MetricFamily, error) {
+		registry := p.getRegistry()
+		if gatherer, ok := registry.(prometheus.Gatherer); ok {
+			return gatherer.Gather()
+		}
+		return nil, fmt.Errorf("registry %T can't be gathered", registry)
+	})
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
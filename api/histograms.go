@@ -1,33 +1,65 @@
 package api
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
 
 type HistogramFacade struct {
-	promMetric prometheus.Histogram
+	metric ObserverInstrument
+	unit   Unit
 }
 
 var DefaultBuckets = prometheus.DefBuckets
 
-func (p *PrometheusMetricsImpl) buildHistogram(builder MetricBuilder, name string, optionalDesc []string) HistogramFacade {
-	return p.getOrAdd(name, TypeHistogram, builder, optionalDesc).(HistogramFacade)
+func (p *PrometheusMetricsImpl) buildHistogram(builder MetricBuilder, name string, unit string, optionalDesc []string) HistogramFacade {
+	return p.getOrAdd(name, TypeHistogram, unit, nil, builder, optionalDesc).(HistogramFacade)
 }
 
 func (p *PrometheusMetricsImpl) Histogram(name string, buckets []float64, optionalDesc ...string) HistogramFacade {
-	return p.buildHistogram(func(p *PrometheusMetricsImpl, fullMetricName string, fullDescription string) interface{} {
-		internal := prometheus.NewHistogram(prometheus.HistogramOpts{Name: fullMetricName, Help: fullDescription, Buckets: buckets})
-		p.Register(internal)
-		return HistogramFacade{promMetric: internal}
-	}, name, optionalDesc)
+	return p.histogram(name, Unit{}, buckets, optionalDesc)
 }
 
+// HistogramForResponseTime observes seconds, or another unit of time given by WithUnit, with DefaultBuckets
+// scaled to suit
 func (p *PrometheusMetricsImpl) HistogramForResponseTime(name string, optionalDesc ...string) HistogramFacade {
+	return p.histogramForResponseTime(name, Unit{}, optionalDesc)
+}
+
+func (p *PrometheusMetricsImpl) histogram(name string, unit Unit, buckets []float64, optionalDesc []string) HistogramFacade {
+	unit = p.declaredUnit(name, unit)
+	name = unit.suffix(name)
+	buckets = p.declaredBuckets(name, buckets)
+	return p.buildHistogram(func(p *PrometheusMetricsImpl, fullMetricName string, fullDescription string) interface{} {
+		return HistogramFacade{metric: p.backend.NewHistogram(InstrumentOpts{Name: fullMetricName, Help: fullDescription, Buckets: buckets, Unit: unit.exposed()}), unit: unit}
+	}, name, unit.expected(), optionalDesc)
+}
+
+func (p *PrometheusMetricsImpl) histogramForResponseTime(name string, unit Unit, optionalDesc []string) HistogramFacade {
+	unit = p.declaredUnit(name, unit).orSeconds()
+	name = unit.suffix(name)
+	buckets := p.declaredBuckets(name, unit.scaleBuckets(DefaultBuckets))
 	return p.buildHistogram(func(p *PrometheusMetricsImpl, fullMetricName string, fullDescription string) interface{} {
-		internal := prometheus.NewHistogram(prometheus.HistogramOpts{Name: fullMetricName, Help: fullDescription, Buckets: DefaultBuckets})
-		p.Register(internal)
-		return HistogramFacade{promMetric: internal}
-	}, name, optionalDesc)
+		return HistogramFacade{metric: p.backend.NewHistogram(InstrumentOpts{Name: fullMetricName, Help: fullDescription, Buckets: buckets, Unit: unit.exposed()}), unit: unit}
+	}, name, unit.expected(), optionalDesc)
 }
 
 func (f HistogramFacade) Update(value float64) {
-	f.promMetric.Observe(value)
+	f.metric.Observe(value)
+}
+
+// UpdateDuration observes d in the histogram's unit of time, or seconds
+func (f HistogramFacade) UpdateDuration(d time.Duration) {
+	f.metric.Observe(f.unit.fromDuration(d))
+}
+
+// UpdateWithExemplar observes value, linked to the trace in exemplar
+func (f HistogramFacade) UpdateWithExemplar(value float64, exemplar Exemplar) {
+	observeWithExemplar(f.metric, value, exemplar)
+}
+
+// UpdateDurationWithExemplar observes d as UpdateDuration does, linked to the trace in exemplar
+func (f HistogramFacade) UpdateDurationWithExemplar(d time.Duration, exemplar Exemplar) {
+	observeWithExemplar(f.metric, f.unit.fromDuration(d), exemplar)
 }
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// NamingPolicy says what to do with names that break the Prometheus naming conventions
+type NamingPolicy int
+
+const (
+	NamingUnchecked NamingPolicy = iota // default
+	NamingWarn                          // log the problems, and create the metric as named
+	NamingFix                           // create the metric with a conforming name instead, e.g. adding _total
+	NamingReject                        // treat the problems as an error, applying the ErrorPolicy
+)
+
+// reservedSuffixes are added by Prometheus itself, so would clash or confuse on user metrics
+var reservedSuffixes = []string{"_bucket", "_count", "_sum", "_created", "_total"}
+
+// lintName checks a new metric's name against the conventions, returning the problems found and a name fixing
+// them. Only the part after the prefix is fixed. unit is the base unit the metric observes, if known. Under
+// UTF8Names any characters, and a leading digit, are valid, so only the other conventions are checked.
+func (p *PrometheusMetricsImpl) lintName(metricKey string, metricType int, unit string, validation NameValidation) (string, []string) {
+	var problems []string
+	fixed := metricKey
+	legacy := validation == LegacyNames
+
+	if invalid := strings.IndexFunc(fixed, func(r rune) bool { return !isNameRune(r) }); legacy && invalid >= 0 {
+		problems = append(problems, "has characters other than [a-zA-Z0-9_]")
+		fixed = strings.Map(func(r rune) rune {
+			if isNameRune(r) {
+				return r
+			}
+			return '_'
+		}, fixed)
+	}
+
+	if full := p.getFullMetricName(fixed); legacy && full != "" && full[0] >= '0' && full[0] <= '9' {
+		problems = append(problems, "starts with a digit")
+		fixed = "_" + fixed
+	}
+
+	if strings.Contains(fixed, "__") || strings.HasSuffix(fixed, "_") {
+		problems = append(problems, "has repeated or trailing underscores")
+		for strings.Contains(fixed, "__") {
+			fixed = strings.ReplaceAll(fixed, "__", "_")
+		}
+		fixed = strings.TrimSuffix(fixed, "_")
+	}
+
+	isCounter := metricType == TypeCounter || metricType == TypeCounterLabels
+	for _, suffix := range reservedSuffixes {
+		if strings.HasSuffix(fixed, suffix) && !(isCounter && suffix == "_total") {
+			problems = append(problems, "ends with "+suffix+", which Prometheus reserves")
+			fixed = strings.TrimSuffix(fixed, suffix)
+		}
+	}
+
+	if unit != "" && !strings.HasSuffix(strings.TrimSuffix(fixed, "_total"), "_"+unit) {
+		problems = append(problems, "has no _"+unit+" unit suffix")
+		if isCounter && strings.HasSuffix(fixed, "_total") {
+			fixed = strings.TrimSuffix(fixed, "_total") + "_" + unit + "_total"
+		} else {
+			fixed += "_" + unit
+		}
+	}
+
+	if isCounter && !strings.HasSuffix(fixed, "_total") {
+		problems = append(problems, "is a counter without the _total suffix")
+		fixed += "_total"
+	}
+	return fixed, problems
+}
+
+// applyNaming lints a new metric's name under the naming policy, returning the key to create it with, or a
+// violation if the policy rejects it
+func (p *PrometheusMetricsImpl) applyNaming(name string, metricKey string, metricType int, unit string) (string, string) {
+	if p.naming == NamingUnchecked {
+		return metricKey, ""
+	}
+
+	fixed, problems := p.lintName(metricKey, metricType, unit, p.nameValidation)
+	if len(problems) == 0 {
+		return metricKey, ""
+	}
+
+	message := fmt.Sprintf("%s %s", p.getFullMetricName(metricKey), strings.Join(problems, ", "))
+	switch p.naming {
+	case NamingWarn:
+		log.Printf("promenade: %s", message)
+	case NamingFix:
+		p.storeNormalisedName(name, fixed) // so later calls with the same name go straight to it
+		return fixed, ""
+	case NamingReject:
+		return metricKey, message
+	}
+	return metricKey, ""
+}
+
+func isNameRune(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
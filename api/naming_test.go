@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintName(t *testing.T) {
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry()})
+
+	for _, each := range []struct {
+		name       string
+		metricType int
+		unit       string
+		fixed      string
+		problems   []string
+	}{
+		{"requests_total", TypeCounter, "", "requests_total", nil},
+		{"requests", TypeCounterLabels, "", "requests_total", []string{"is a counter without the _total suffix"}},
+		{"1bad", TypeGauge, "", "_1bad", []string{"starts with a digit"}},
+		{"a__b_", TypeGauge, "", "a_b", []string{"has repeated or trailing underscores"}},
+		{"a:b/c", TypeGauge, "", "a_b_c", []string{"has characters other than [a-zA-Z0-9_]"}},
+		{"latency_bucket", TypeHistogram, "", "latency", []string{"ends with _bucket, which Prometheus reserves"}},
+		{"connections_total", TypeGauge, "", "connections", []string{"ends with _total, which Prometheus reserves"}},
+		{"calc", TypeSummary, "seconds", "calc_seconds", []string{"has no _seconds unit suffix"}},
+		{"calc_seconds", TypeSummaryLabels, "seconds", "calc_seconds", nil},
+		{"time_total", TypeCounter, "seconds", "time_seconds_total", []string{"has no _seconds unit suffix"}},
+	} {
+		fixed, problems := metrics.lintName(each.name, each.metricType, each.unit, LegacyNames)
+		assert.Equal(t, each.fixed, fixed, each.name)
+		assert.Equal(t, each.problems, problems, each.name)
+	}
+
+	prefixed := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc"})
+	fixed, problems := prefixed.lintName("1bad", TypeGauge, "", LegacyNames)
+	assert.Equal(t, "1bad", fixed)
+	assert.Empty(t, problems)
+}
+
+func TestNamingFix(t *testing.T) {
+	for _, caseSensitive := range []bool{false, true} {
+		metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc", Naming: NamingFix, CaseSensitiveMetricNames: caseSensitive})
+		metrics.Counter("requests").Inc()
+		metrics.Counter("requests").Inc()
+		metrics.Timer("calc").Stop()
+		metrics.HistogramForResponseTime("db.latency").Update(1)
+		metrics.Gauge("connections").Inc()
+
+		assert.ElementsMatch(t, []string{"svc_calc_seconds", "svc_connections", "svc_db_latency_seconds", "svc_requests_total"}, metrics.TestHelper().MetricNames())
+		assert.Equal(t, "svc_requests_total", metrics.TestHelper().MetricName("requests"))
+		assertValue(t, 2)(metrics.TestHelper().CounterValue("requests"))
+	}
+}
+
+func TestNamingWarnAndReject(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	warned := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc", Naming: NamingWarn})
+	warned.Counter("requests").Inc()
+	assert.Contains(t, buf.String(), "promenade: svc_requests is a counter without the _total suffix\n")
+	assert.Equal(t, []string{"svc_requests"}, warned.TestHelper().MetricNames())
+
+	rejected := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc", Naming: NamingReject})
+	assert.PanicsWithValue(t, "svc_sizes_count ends with _count, which Prometheus reserves, has no _seconds unit suffix", func() { rejected.HistogramForResponseTime("sizes_count") })
+	rejected.Counter("requests_total").Inc()
+	assert.Equal(t, []string{"svc_requests_total"}, rejected.TestHelper().MetricNames())
+
+	ignored := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), Naming: NamingReject, ErrorPolicy: IgnoreOnError})
+	ignored.Counter("requests").Inc()
+	assert.Empty(t, ignored.TestHelper().MetricNames())
+}
+
+func TestLegacyNames(t *testing.T) {
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry()})
+	metrics.CounterWithLabels("http/requests€.Total", []string{"content-type", "1st", "ok"}).IncLabel("json", "a", "b")
+	metrics.Gauge("1bad").Inc()
+	metrics.TimerWithLabel("calc time", "pi:digits", "10").Stop()
+
+	assert.ElementsMatch(t, []string{"http_requests__total", "_1bad", "calc_time"}, metrics.TestHelper().MetricNames())
+	assert.Equal(t, "http_requests__total", metrics.TestHelper().MetricName("http/requests€.Total"))
+
+	labels := metrics.TestHelper().GetMetricLabelValues("http_requests__total")
+	assert.Contains(t, labels, "content_type")
+	assert.Contains(t, labels, "_1st")
+	assert.Contains(t, metrics.TestHelper().GetMetricLabelValues("calc_time"), "pi_digits")
+
+	assert.Equal(t, "svc_a_b", NormaliseAndLowercaseName("Svc_A/B"))
+}
+
+func TestUTF8Names(t *testing.T) {
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc", NameValidation: UTF8Names})
+	metrics.CounterWithLabel("HTTP.Requests", "content-type").IncLabel("json")
+	metrics.Gauge("temp.°C").SetValue(21)
+	metrics.Counter("bad\xffbyte").Inc()
+
+	assert.ElementsMatch(t, []string{"svc_http.requests", "svc_temp.°c", "svc_bad_byte"}, metrics.TestHelper().MetricNames())
+	assertValue(t, 1)(metrics.TestHelper().CounterValue("http.requests", "json"))
+
+	snapshot, err := metrics.TestHelper().Snapshot(false)
+	assert.NoError(t, err)
+	assert.Contains(t, snapshot, `{"svc_http.requests","content-type"="json"} 1`)
+	assert.Contains(t, snapshot, `{"svc_temp.°c"} 21`)
+
+	sensitive := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), NameValidation: UTF8Names, CaseSensitiveMetricNames: true})
+	sensitive.Counter("HTTP.Requests").Inc()
+	assert.Equal(t, []string{"HTTP.Requests"}, sensitive.TestHelper().MetricNames())
+}
+
+func TestUTF8NamingFixAndReject(t *testing.T) {
+	fixed := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc", NameValidation: UTF8Names, Naming: NamingFix})
+	fixed.Counter("http.requests").Inc()
+	fixed.Gauge("temp.°C").SetValue(21)
+	fixed.Gauge("1st.place").SetValue(1)
+	assert.ElementsMatch(t, []string{"svc_http.requests_total", "svc_temp.°c", "svc_1st.place"}, fixed.TestHelper().MetricNames())
+
+	rejected := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), NameValidation: UTF8Names, Naming: NamingReject})
+	rejected.Counter("http.requests_total").Inc()
+	rejected.Gauge("temp.°C").SetValue(21)
+	rejected.Gauge("1st.place").SetValue(1)
+	assert.ElementsMatch(t, []string{"http.requests_total", "temp.°c", "1st.place"}, rejected.TestHelper().MetricNames())
+	assert.PanicsWithValue(t, "http.errors is a counter without the _total suffix", func() { rejected.Counter("http.errors") })
+}
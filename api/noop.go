@@ -0,0 +1,174 @@
+package api
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type noopMetrics struct{}
+
+// NewNoopMetrics returns metrics that record and register nothing, as a cheap default for libraries. Calls
+// do not allocate, though label names passed as a slice escape to the heap, so build labelled facades once.
+func NewNoopMetrics() PrometheusMetrics {
+	return noopMetrics{}
+}
+
+// NewNoopBackend returns a Backend whose instruments discard everything. Unlike NewNoopMetrics, names are still
+// normalised and checked for reuse with different types.
+func NewNoopBackend() Backend {
+	return noopBackend{}
+}
+
+func (noopMetrics) Register(prometheus.Collector) error {
+	return nil
+}
+
+func (noopMetrics) MustRegister(prometheus.Collector) {}
+
+func (noopMetrics) TestHelper() *TestHelper {
+	registry := prometheus.NewRegistry()
+	return &TestHelper{metrics: &PrometheusMetricsImpl{registry: registry,
+		registrations:   newMetricRegistrations(),
+		normalisedNames: newNormalisedNames(),
+		clock:           systemClock{},
+		backend:         noopBackend{}}}
+}
+
+func (noopMetrics) Counter(string, ...string) CounterFacade {
+	return CounterFacade{metric: noopInstrument{}}
+}
+
+func (noopMetrics) CounterWithLabel(string, string, ...string) LabelledCounterFacade {
+	return LabelledCounterFacade{}
+}
+
+func (noopMetrics) CounterWithLabels(string, []string, ...string) LabelledCounterFacade {
+	return LabelledCounterFacade{}
+}
+
+func (noopMetrics) Error(string) ErrorCounter {
+	return ErrorCounter{metric: noopCounterVec{}}
+}
+
+func (noopMetrics) Gauge(string, ...string) GaugeFacade {
+	return GaugeFacade{metric: noopInstrument{}}
+}
+
+func (noopMetrics) GaugeWithLabel(string, string, ...string) LabelledGaugeFacade {
+	return LabelledGaugeFacade{}
+}
+
+func (noopMetrics) GaugeWithLabels(string, []string, ...string) LabelledGaugeFacade {
+	return LabelledGaugeFacade{}
+}
+
+func (noopMetrics) Histogram(string, []float64, ...string) HistogramFacade {
+	return HistogramFacade{metric: noopInstrument{}}
+}
+
+func (noopMetrics) HistogramForResponseTime(string, ...string) HistogramFacade {
+	return HistogramFacade{metric: noopInstrument{}}
+}
+
+func (noopMetrics) Summary(string, ...string) SummaryFacade {
+	return SummaryFacade{metric: noopInstrument{}}
+}
+
+func (noopMetrics) SummaryWithLabel(string, string, ...string) LabelledSummaryFacade {
+	return LabelledSummaryFacade{}
+}
+
+func (noopMetrics) SummaryWithLabels(string, []string, ...string) LabelledSummaryFacade {
+	return LabelledSummaryFacade{}
+}
+
+func (noopMetrics) Timer(string) *Stopwatch {
+	return nil
+}
+
+func (noopMetrics) TimerWithLabel(string, string, string) *Stopwatch {
+	return nil
+}
+
+func (noopMetrics) TimerWithLabels(string, []string, ...string) *Stopwatch {
+	return nil
+}
+
+func (noopMetrics) Time(_ string, fn func() error) error {
+	return fn()
+}
+
+// SLO still tracks burn rates in-process, though nothing is exposed
+func (noopMetrics) SLO(name string, target float64, latencyThreshold time.Duration, windows ...time.Duration) *SLO {
+	return newSLO(noopMetrics{}, systemClock{}, name, target, latencyThreshold, windows)
+}
+
+func (m noopMetrics) WithUnit(Unit) PrometheusMetrics {
+	return m
+}
+
+type noopBackend struct{}
+
+func (noopBackend) NewCounter(InstrumentOpts) CounterInstrument {
+	return noopInstrument{}
+}
+
+func (noopBackend) NewCounterVec(InstrumentOpts) CounterVecInstrument {
+	return noopCounterVec{}
+}
+
+func (noopBackend) NewGauge(InstrumentOpts) GaugeInstrument {
+	return noopInstrument{}
+}
+
+func (noopBackend) NewGaugeVec(InstrumentOpts) GaugeVecInstrument {
+	return noopGaugeVec{}
+}
+
+func (noopBackend) NewHistogram(InstrumentOpts) ObserverInstrument {
+	return noopInstrument{}
+}
+
+func (noopBackend) NewSummary(InstrumentOpts) ObserverInstrument {
+	return noopInstrument{}
+}
+
+func (noopBackend) NewSummaryVec(InstrumentOpts) ObserverVecInstrument {
+	return noopObserverVec{}
+}
+
+func (noopBackend) NewTimer(InstrumentOpts) ObserverInstrument {
+	return noopInstrument{}
+}
+
+func (noopBackend) NewTimerVec(InstrumentOpts) ObserverVecInstrument {
+	return noopObserverVec{}
+}
+
+type noopInstrument struct{}
+
+func (noopInstrument) Inc()            {}
+func (noopInstrument) Dec()            {}
+func (noopInstrument) Add(float64)     {}
+func (noopInstrument) Sub(float64)     {}
+func (noopInstrument) Set(float64)     {}
+func (noopInstrument) Observe(float64) {}
+
+type noopCounterVec struct{}
+
+func (noopCounterVec) WithLabelValues(...string) CounterInstrument {
+	return noopInstrument{}
+}
+
+type noopGaugeVec struct{}
+
+func (noopGaugeVec) WithLabelValues(...string) GaugeInstrument {
+	return noopInstrument{}
+}
+
+type noopObserverVec struct{}
+
+func (noopObserverVec) WithLabelValues(...string) ObserverInstrument {
+	return noopInstrument{}
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	sloEventsName     = "slo_events_total"
+	sloGoodEventsName = "slo_good_events_total"
+	sloBurnRateName   = "slo_burn_rate"
+	sloObjectiveName  = "slo_objective"
+	sloBucketsPerSpan = 10 // buckets in the shortest window
+)
+
+// DefaultSLOWindows pairs short and long windows, as in the multi-window burn-rate alerts of the SRE workbook
+var DefaultSLOWindows = []time.Duration{5 * time.Minute, 30 * time.Minute, time.Hour, 6 * time.Hour}
+
+// SLO counts good and total events for a service level objective, and reports how fast its error budget is
+// burning over each window, both as gauges and from BurnRate, so services can report their own health without
+// querying Prometheus. A burn rate of 1 uses up exactly the budget over the SLO period; alert on more. The gauges
+// are brought up to date when scraped, or on each event for backends that push instead.
+type SLO struct {
+	name             string
+	target           float64
+	latencyThreshold time.Duration
+	windows          []time.Duration
+	clock            Clock
+
+	events     LabelledCounterFacade
+	goodEvents LabelledCounterFacade
+	burnRates  LabelledGaugeFacade
+	scraped    bool // burn rates are updated by the scrape, not each event
+
+	sync.Mutex
+	resolution time.Duration
+	buckets    []sloBucket
+}
+
+type sloBucket struct {
+	index int64 // time since the epoch, in units of resolution
+	good  uint64
+	total uint64
+}
+
+type sloRegistry struct {
+	sync.Mutex
+	internal map[string]*SLO
+}
+
+// get returns the SLO already created with name, ignoring any different target, threshold or windows
+func (r *sloRegistry) get(name string, build func() *SLO) *SLO {
+	r.Lock()
+	defer r.Unlock()
+	if slo, ok := r.internal[name]; ok {
+		return slo
+	}
+	if r.internal == nil {
+		r.internal = make(map[string]*SLO)
+	}
+	slo := build()
+	r.internal[name] = slo
+	return slo
+}
+
+func (p *PrometheusMetricsImpl) SLO(name string, target float64, latencyThreshold time.Duration, windows ...time.Duration) *SLO {
+	return p.slos.get(name, func() *SLO { return newSLO(p, p.clock, name, target, latencyThreshold, windows) })
+}
+
+// newSLO creates the SLO's metrics through metrics, so it works the same for any implementation. A target
+// outside (0, 1) panics, as every burn rate would be meaningless. Windows that aren't positive are handled by
+// the ErrorPolicy, and otherwise left out.
+func newSLO(metrics PrometheusMetrics, clock Clock, name string, target float64, latencyThreshold time.Duration, windows []time.Duration) *SLO {
+	if target <= 0 || target >= 1 {
+		panic(fmt.Sprintf("SLO %s has target %v, which must be between 0 and 1", name, target))
+	}
+
+	var valid []time.Duration
+	for _, each := range windows {
+		if each <= 0 {
+			applyErrorPolicy(errorPolicyOf(metrics), fmt.Sprintf("SLO %s has window %v, which must be positive", name, each))
+			continue
+		}
+		valid = append(valid, each)
+	}
+	windows = valid
+	if len(windows) == 0 {
+		windows = DefaultSLOWindows
+	}
+
+	shortest, longest := windows[0], windows[0]
+	for _, each := range windows {
+		if each < shortest {
+			shortest = each
+		}
+		if each > longest {
+			longest = each
+		}
+	}
+
+	resolution := shortest / sloBucketsPerSpan
+	if resolution <= 0 {
+		resolution = shortest
+	}
+
+	slo := &SLO{name: name,
+		target:           target,
+		latencyThreshold: latencyThreshold,
+		windows:          windows,
+		clock:            clock,
+		events:           metrics.CounterWithLabel(sloEventsName, "slo", "Events counted towards each SLO"),
+		goodEvents:       metrics.CounterWithLabel(sloGoodEventsName, "slo", "Events meeting each SLO"),
+		burnRates:        metrics.GaugeWithLabels(sloBurnRateName, []string{"slo", "window"}, "Error budget burn rate of each SLO"),
+		resolution:       resolution,
+		buckets:          make([]sloBucket, int(longest/resolution)+1),
+	}
+	metrics.GaugeWithLabel(sloObjectiveName, "slo", "Target proportion of good events for each SLO").SetLabels(name).Value(target)
+	slo.scraped = slo.burnRates.beforeCollect(slo.updateBurnRates)
+	return slo
+}
+
+// Record counts an event, which is good if err is nil and, given a latency threshold, duration is within it
+func (s *SLO) Record(duration time.Duration, err error) {
+	good := err == nil && (s.latencyThreshold <= 0 || duration <= s.latencyThreshold)
+
+	s.events.IncLabel(s.name)
+	if good {
+		s.goodEvents.IncLabel(s.name)
+	}
+
+	s.Lock()
+	bucket := s.bucket(s.clock.Now())
+	bucket.total++
+	if good {
+		bucket.good++
+	}
+	s.Unlock()
+
+	if !s.scraped {
+		s.updateBurnRates()
+	}
+}
+
+// updateBurnRates sets the gauges, so events that have left a window no longer count towards it
+func (s *SLO) updateBurnRates() {
+	s.Lock()
+	defer s.Unlock()
+
+	now := s.clock.Now()
+	for _, window := range s.windows {
+		s.burnRates.SetLabels(s.name, formatWindow(window)).Value(s.burnRate(now, window))
+	}
+}
+
+// BurnRate is the proportion of bad events over the window, divided by the proportion the target allows
+func (s *SLO) BurnRate(window time.Duration) float64 {
+	s.Lock()
+	defer s.Unlock()
+	return s.burnRate(s.clock.Now(), window)
+}
+
+// Target is the proportion of events that should be good
+func (s *SLO) Target() float64 {
+	return s.target
+}
+
+// bucket returns the bucket for now, emptying it if it last held an older span
+func (s *SLO) bucket(now time.Time) *sloBucket {
+	index := now.UnixNano() / int64(s.resolution)
+	bucket := &s.buckets[index%int64(len(s.buckets))]
+	if bucket.index != index {
+		*bucket = sloBucket{index: index}
+	}
+	return bucket
+}
+
+func (s *SLO) burnRate(now time.Time, window time.Duration) float64 {
+	index := now.UnixNano() / int64(s.resolution)
+	oldest := index - int64(window/s.resolution)
+
+	var good, total uint64
+	for _, each := range s.buckets {
+		if each.index > oldest && each.index <= index {
+			good += each.good
+			total += each.total
+		}
+	}
+
+	if total == 0 {
+		return 0
+	}
+	return (float64(total-good) / float64(total)) / (1 - s.target)
+}
+
+// formatWindow gives e.g. 5m rather than 5m0s, to match Prometheus durations
+func formatWindow(window time.Duration) string {
+	switch {
+	case window%time.Hour == 0:
+		return fmt.Sprintf("%dh", window/time.Hour)
+	case window%time.Minute == 0:
+		return fmt.Sprintf("%dm", window/time.Minute)
+	case window%time.Second == 0:
+		return fmt.Sprintf("%ds", window/time.Second)
+	default:
+		return window.String()
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSLO(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc", Clock: clock})
+
+	slo := metrics.SLO("checkout", 0.99, 300*time.Millisecond)
+	assert.Same(t, slo, metrics.SLO("checkout", 0.5, time.Second))
+
+	for i := 0; i < 97; i++ {
+		slo.Record(100*time.Millisecond, nil)
+	}
+	slo.Record(time.Second, nil)                   // too slow
+	slo.Record(time.Millisecond, errors.New("no")) // failed
+	slo.Record(300*time.Millisecond, nil)          // just in time
+
+	helper := metrics.TestHelper()
+	assertValue(t, 100)(helper.CounterValue("slo_events_total", "checkout"))
+	assertValue(t, 98)(helper.CounterValue("slo_good_events_total", "checkout"))
+	assertValue(t, 0.99)(helper.GaugeValue("slo_objective", "checkout"))
+	assert.InDelta(t, 2, slo.BurnRate(5*time.Minute), 1e-9)
+	assertDelta(t, 2)(helper.GaugeValue("slo_burn_rate", "checkout", "5m"))
+	assertDelta(t, 2)(helper.GaugeValue("slo_burn_rate", "checkout", "6h"))
+
+	// the bad events leave the short windows, but not the long ones
+	clock.Advance(40 * time.Minute)
+	slo.Record(time.Millisecond, nil)
+	assert.Zero(t, slo.BurnRate(5*time.Minute))
+	assertValue(t, 0)(helper.GaugeValue("slo_burn_rate", "checkout", "30m"))
+	assertDelta(t, 200.0/101)(helper.GaugeValue("slo_burn_rate", "checkout", "1h"))
+
+	clock.Advance(7 * time.Hour)
+	assert.Zero(t, slo.BurnRate(6*time.Hour))
+}
+
+func TestSLOWindows(t *testing.T) {
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry()})
+	slo := metrics.SLO("api", 0.9, 0, time.Minute, 10*time.Minute)
+	slo.Record(time.Hour, nil) // no latency threshold
+	slo.Record(0, errors.New("no"))
+
+	windows := metrics.TestHelper().GetMetricLabelValues("slo_burn_rate")["window"]
+	assert.Len(t, windows, 2)
+	assert.Contains(t, windows, "1m")
+	assert.Contains(t, windows, "10m")
+	assert.InDelta(t, 5, slo.BurnRate(time.Minute), 1e-9)
+
+	assert.PanicsWithValue(t, "SLO bad has target 1, which must be between 0 and 1", func() { metrics.SLO("bad", 1, 0) })
+}
+
+func TestSLOBurnRatesWhenScraped(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), Clock: clock})
+	slo := metrics.SLO("api", 0.9, 0, time.Minute, 10*time.Minute)
+	slo.Record(0, errors.New("no"))
+
+	helper := metrics.TestHelper()
+	assertDelta(t, 10)(helper.GaugeValue("slo_burn_rate", "api", "1m"))
+
+	// no more traffic, but the failure leaves the short window
+	clock.Advance(2 * time.Minute)
+	assertValue(t, 0)(helper.GaugeValue("slo_burn_rate", "api", "1m"))
+	assertDelta(t, 10)(helper.GaugeValue("slo_burn_rate", "api", "10m"))
+}
+
+func TestSLOBurnRatesWhenPushed(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	recorder := NewRecordingBackend()
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), Backend: recorder, Clock: clock})
+	slo := metrics.SLO("api", 0.9, 0, time.Minute)
+	assert.False(t, slo.scraped)
+
+	slo.Record(0, errors.New("no"))
+	assert.InDelta(t, 10, recorder.GaugeValue("slo_burn_rate", "api", "1m"), 1e-9)
+
+	scraped := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry()})
+	assert.True(t, scraped.SLO("api", 0.9, 0).scraped)
+	assert.False(t, NewFanoutMetrics(&scraped, &metrics).SLO("api", 0.9, 0).scraped) // the recorder still needs each event
+}
+
+func TestInvalidSLOWindows(t *testing.T) {
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry()})
+	assert.PanicsWithValue(t, "SLO bad has window 0s, which must be positive", func() { metrics.SLO("bad", 0.9, 0, time.Minute, 0) })
+
+	lenient := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), ErrorPolicy: IgnoreOnError})
+	slo := lenient.SLO("api", 0.9, 0, -time.Minute)
+	slo.Record(0, errors.New("no"))
+	assert.InDelta(t, 10, slo.BurnRate(5*time.Minute), 1e-9) // the default windows
+	assert.Len(t, lenient.TestHelper().GetMetricLabelValues("slo_burn_rate")["window"], len(DefaultSLOWindows))
+}
+
+func TestNoopAndFanoutSLO(t *testing.T) {
+	noop := NewNoopMetrics().SLO("api", 0.9, 0)
+	noop.Record(0, errors.New("no"))
+	assert.InDelta(t, 10, noop.BurnRate(time.Hour), 1e-9)
+
+	first := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry()})
+	second := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry()})
+	fanout := NewFanoutMetrics(&first, &second)
+	fanout.SLO("api", 0.9, 0).Record(0, nil)
+	assert.Same(t, fanout.SLO("api", 0.9, 0), fanout.SLO("api", 0.9, 0))
+	assert.Same(t, fanout.SLO("api", 0.9, 0), fanout.WithUnit(Milliseconds).SLO("api", 0.9, 0))
+	assert.Same(t, first.SLO("api", 0.9, 0), first.WithUnit(Milliseconds).SLO("api", 0.9, 0))
+
+	assertValue(t, 1)(first.TestHelper().CounterValue("slo_good_events_total", "api"))
+	assertValue(t, 1)(second.TestHelper().CounterValue("slo_good_events_total", "api"))
+}
+
+func assertValue(t *testing.T, expected float64) func(float64, error) {
+	return func(actual float64, err error) {
+		t.Helper()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func assertDelta(t *testing.T, expected float64) func(float64, error) {
+	return func(actual float64, err error) {
+		t.Helper()
+		assert.NoError(t, err)
+		assert.InDelta(t, expected, actual, 1e-9)
+	}
+}
+
+func TestSLONamingReject(t *testing.T) {
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc", Naming: NamingReject})
+
+	assert.NotPanics(t, func() { metrics.SLO("checkout", 0.99, 0).Record(time.Millisecond, nil) })
+	assertValue(t, 1)(metrics.TestHelper().CounterValue("slo_events_total", "checkout"))
+}
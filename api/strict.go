@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+type ErrorPolicy int
+
+const (
+	PanicOnError  ErrorPolicy = iota // default
+	LogOnError                       // log, and return a facade that discards everything
+	IgnoreOnError                    // silently return a facade that discards everything
+)
+
+const undeclaredMetricName = "promenade_undeclared_metric_total"
+
+// discarding builds the facades returned after an error, whatever the backend
+var discarding = &PrometheusMetricsImpl{backend: noopBackend{}}
+
+// internalMetrics are those promenade creates for itself, e.g. for SLOs, so they needn't be declared
+var internalMetrics = map[string]bool{sloEventsName: true, sloGoodEventsName: true, sloBurnRateName: true, sloObjectiveName: true}
+
+// checkDeclared returns why a new metric may not be created in strict mode, or "" if it may. Names only in
+// MetricDescriptions are accepted whatever their type and labels, as are internal metrics.
+func (p *PrometheusMetricsImpl) checkDeclared(metricKey string, metricType int, labelNames []string) string {
+	if internalMetrics[metricKey] {
+		return ""
+	}
+	fullName := p.getFullMetricName(metricKey)
+
+	definition, ok := p.definitions[metricKey]
+	if !ok {
+		if _, described := p.descriptions[metricKey]; described {
+			return ""
+		}
+		return fullName + " is not declared"
+	}
+
+	if declared := definition.Type; declared != typeNames[metricType] && !(declared == CatalogueTimer && typeNames[metricType] == CatalogueSummary) {
+		return fmt.Sprintf("%s is declared as a %s, not a %s", fullName, declared, typeNames[metricType])
+	}
+	if strings.Join(definition.Labels, ",") != strings.Join(labelNames, ",") {
+		return fmt.Sprintf("%s is declared with labels %v, not %v", fullName, definition.Labels, labelNames)
+	}
+	return ""
+}
+
+// typeNames gives the catalogue type for each metric type, timers being summaries
+var typeNames = map[int]string{
+	TypeCounter:       CatalogueCounter,
+	TypeCounterLabels: CatalogueCounter,
+	TypeGauge:         CatalogueGauge,
+	TypeGaugeLabels:   CatalogueGauge,
+	TypeSummary:       CatalogueSummary,
+	TypeSummaryLabels: CatalogueSummary,
+	TypeHistogram:     CatalogueHistogram,
+}
+
+// handleError applies the error policy, returning a discarding facade of the type builder makes if it doesn't panic
+func (p *PrometheusMetricsImpl) handleError(message string, fullName string, builder MetricBuilder) metricFacade {
+	applyErrorPolicy(p.errorPolicy, message)
+	return builder(discarding, fullName, fullName)
+}
+
+// applyErrorPolicy panics with message, logs it, or ignores it
+func applyErrorPolicy(policy ErrorPolicy, message string) {
+	switch policy {
+	case LogOnError:
+		log.Printf("promenade: %s", message)
+	case IgnoreOnError:
+	default:
+		panic(message)
+	}
+}
+
+// errorPolicyOf returns the policy metrics apply, or PanicOnError for implementations without one
+func errorPolicyOf(metrics PrometheusMetrics) ErrorPolicy {
+	if policed, ok := metrics.(interface{ getErrorPolicy() ErrorPolicy }); ok {
+		return policed.getErrorPolicy()
+	}
+	return PanicOnError
+}
+
+func (p *PrometheusMetricsImpl) getErrorPolicy() ErrorPolicy {
+	return p.errorPolicy
+}
+
+func (p *PrometheusMetricsImpl) countUndeclared(fullName string) {
+	p.registrations.Lock()
+	if p.undeclaredCounter == nil {
+		p.undeclaredCounter = p.backend.NewCounterVec(InstrumentOpts{Name: undeclaredMetricName,
+			Help:       "Attempts to create metrics not declared in the catalogue",
+			LabelNames: []string{"metric"}})
+	}
+	counter := p.undeclaredCounter
+	p.registrations.Unlock()
+
+	counter.WithLabelValues(fullName).Inc()
+}
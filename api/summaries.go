@@ -1,27 +1,36 @@
 package api
 
-import "github.com/prometheus/client_golang/prometheus"
+import "time"
 
 type SummaryFacade struct {
-	promMetric prometheus.Summary
+	metric ObserverInstrument
+	unit   Unit
 }
 
 var (
 	DefaultObjectives = map[float64]float64{0.5: 0.01, 0.75: 0.01, 0.9: 0.01, 0.95: 0.01, 0.99: 0.01, 0.999: 0.01}
 )
 
-func (p *PrometheusMetricsImpl) buildSummary(builder MetricBuilder, name string, optionalDesc []string) SummaryFacade {
-	return p.getOrAdd(name, TypeSummary, builder, optionalDesc).(SummaryFacade)
+func (p *PrometheusMetricsImpl) buildSummary(builder MetricBuilder, name string, unit string, optionalDesc []string) SummaryFacade {
+	return p.getOrAdd(name, TypeSummary, unit, nil, builder, optionalDesc).(SummaryFacade)
 }
 
 func (p *PrometheusMetricsImpl) Summary(name string, optionalDesc ...string) SummaryFacade {
+	return p.summary(name, Unit{}, optionalDesc)
+}
+
+func (p *PrometheusMetricsImpl) summary(name string, unit Unit, optionalDesc []string) SummaryFacade {
+	unit = p.declaredUnit(name, unit)
 	return p.buildSummary(func(p *PrometheusMetricsImpl, fullMetricName string, fullDescription string) interface{} {
-		internal := prometheus.NewSummary(prometheus.SummaryOpts{Name: fullMetricName, Help: fullDescription, Objectives: DefaultObjectives})
-		p.Register(internal)
-		return SummaryFacade{promMetric: internal}
-	}, name, optionalDesc)
+		return SummaryFacade{metric: p.backend.NewSummary(InstrumentOpts{Name: fullMetricName, Help: fullDescription, Unit: unit.exposed()}), unit: unit}
+	}, unit.suffix(name), unit.expected(), optionalDesc)
 }
 
 func (f SummaryFacade) Observe(value float64) {
-	f.promMetric.Observe(value)
+	f.metric.Observe(value)
+}
+
+// ObserveDuration observes d in the summary's unit of time, or seconds
+func (f SummaryFacade) ObserveDuration(d time.Duration) {
+	f.metric.Observe(f.unit.fromDuration(d))
 }
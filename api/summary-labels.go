@@ -1,13 +1,14 @@
 package api
 
-import "github.com/prometheus/client_golang/prometheus"
+import "time"
 
 type LabelledSummaryFacade struct {
-	promMetric *prometheus.SummaryVec
+	metric ObserverVecInstrument
+	unit   Unit
 }
 
-func (p *PrometheusMetricsImpl) buildLabelledSummary(builder MetricBuilder, name string, optionalDesc []string) LabelledSummaryFacade {
-	return p.getOrAdd(name, TypeSummaryLabels, builder, optionalDesc).(LabelledSummaryFacade)
+func (p *PrometheusMetricsImpl) buildLabelledSummary(builder MetricBuilder, name string, unit string, labelNames []string, optionalDesc []string) LabelledSummaryFacade {
+	return p.getOrAdd(name, TypeSummaryLabels, unit, labelNames, builder, optionalDesc).(LabelledSummaryFacade)
 }
 
 func (p *PrometheusMetricsImpl) SummaryWithLabel(name string, labelName string, optionalDesc ...string) LabelledSummaryFacade {
@@ -15,13 +16,32 @@ func (p *PrometheusMetricsImpl) SummaryWithLabel(name string, labelName string,
 }
 
 func (p *PrometheusMetricsImpl) SummaryWithLabels(name string, labelNames []string, optionalDesc ...string) LabelledSummaryFacade {
+	return p.labelledSummary(name, Unit{}, labelNames, optionalDesc)
+}
+
+func (p *PrometheusMetricsImpl) labelledSummary(name string, unit Unit, labelNames []string, optionalDesc []string) LabelledSummaryFacade {
+	unit = p.declaredUnit(name, unit)
+	labelNames = p.normaliseLabelNames(labelNames)
 	return p.buildLabelledSummary(func(p *PrometheusMetricsImpl, fullMetricName string, fullDescription string) interface{} {
-		internal := prometheus.NewSummaryVec(prometheus.SummaryOpts{Name: fullMetricName, Help: fullDescription, Objectives: DefaultObjectives}, labelNames)
-		p.Register(internal)
-		return LabelledSummaryFacade{promMetric: internal}
-	}, name, optionalDesc)
+		return LabelledSummaryFacade{metric: p.backend.NewSummaryVec(InstrumentOpts{Name: fullMetricName, Help: fullDescription, LabelNames: labelNames, Unit: unit.exposed()}), unit: unit}
+	}, unit.suffix(name), unit.expected(), labelNames, optionalDesc)
 }
 
 func (f LabelledSummaryFacade) Observe(value float64, labelValues ...string) {
-	f.promMetric.WithLabelValues(labelValues...).Observe(value)
+	f.series(labelValues).Observe(value)
+}
+
+// ObserveDuration observes d in the summary's unit of time, or seconds
+func (f LabelledSummaryFacade) ObserveDuration(d time.Duration, labelValues ...string) {
+	f.series(labelValues).Observe(f.unit.fromDuration(d))
+}
+
+// series returns the summary with the label values, copied as for LabelledCounterFacade
+func (f LabelledSummaryFacade) series(labelValues []string) ObserverInstrument {
+	if f.metric == nil {
+		return noopInstrument{}
+	}
+	values := make([]string, len(labelValues))
+	copy(values, labelValues)
+	return f.metric.WithLabelValues(values...)
 }
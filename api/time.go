@@ -0,0 +1,42 @@
+package api
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Time runs fn, timing it as Timer(name) does, and counting it by outcome in name_calls_total, e.g.
+// prefix_fetch_calls_total{outcome="failure"}, which OpenMetrics can't confuse with the timer's family. Errors
+// are also counted by Error(name), and returned.
+func (p *PrometheusMetricsImpl) Time(name string, fn func() error) error {
+	return timeFunc(p, name, fn)
+}
+
+func (m unitMetrics) Time(name string, fn func() error) error {
+	return timeFunc(m, name, fn)
+}
+
+func (f *fanoutMetrics) Time(name string, fn func() error) error {
+	return timeFunc(f, name, fn)
+}
+
+// TimeResult runs fn, timing and counting it as Time does, and returns its result
+func TimeResult[T any](metrics PrometheusMetrics, name string, fn func() (T, error)) (T, error) {
+	stopwatch := metrics.Timer(name)
+	result, err := fn()
+	stopwatch.Stop()
+
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeFailure
+		metrics.Error(name)
+	}
+	// without any unit, which describes the timer, not the count
+	metrics.WithUnit(Unit{}).CounterWithLabel(name+"_calls_total", "outcome").IncLabel(outcome)
+	return result, err
+}
+
+func timeFunc(metrics PrometheusMetrics, name string, fn func() error) error {
+	_, err := TimeResult(metrics, name, func() (struct{}, error) { return struct{}{}, fn() })
+	return err
+}
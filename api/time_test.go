@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTime(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc", Clock: clock})
+
+	assert.NoError(t, metrics.Time("fetch", func() error {
+		clock.Advance(2 * time.Second)
+		return nil
+	}))
+	assert.EqualError(t, metrics.Time("fetch", func() error {
+		clock.Advance(time.Second)
+		return errors.New("timeout")
+	}), "timeout")
+
+	helper := metrics.TestHelper()
+	assertValue(t, 1)(helper.CounterValue("fetch_calls_total", OutcomeSuccess))
+	assertValue(t, 1)(helper.CounterValue("fetch_calls_total", OutcomeFailure))
+	assertValue(t, 1)(helper.CounterValue("errors", "fetch"))
+
+	summary, err := helper.SummarySnapshot("fetch")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), summary.Count)
+	assert.Equal(t, 3.0, summary.Sum)
+}
+
+func TestTimeOpenMetricsFamilies(t *testing.T) {
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc"})
+	assert.NoError(t, metrics.Time("fetch", func() error { return nil }))
+
+	server := httptest.NewServer(metrics.Handler())
+	defer server.Close()
+
+	body := get(t, server.URL, "application/openmetrics-text; version=1.0.0")
+	assert.Equal(t, 1, strings.Count(body, "# TYPE svc_fetch summary\n"))
+	assert.Equal(t, 1, strings.Count(body, "# TYPE svc_fetch_calls counter\n"))
+	assert.NotContains(t, body, "# TYPE svc_fetch counter")
+	assert.Contains(t, body, `svc_fetch_calls_total{outcome="success"} 1.0`)
+}
+
+func TestStrictTime(t *testing.T) {
+	catalogue, err := ParseCatalogue([]byte("metrics: [{name: checkout, type: timer}, {name: parse, type: timer, unit: milliseconds}]"))
+	assert.NoError(t, err)
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), Catalogue: catalogue, Strict: true})
+
+	assert.NotPanics(t, func() { _ = metrics.Time("checkout", func() error { return nil }) })
+	assert.NotPanics(t, func() {
+		_, _ = TimeResult(metrics.WithUnit(Milliseconds), "parse", func() (int, error) { return 1, nil })
+	})
+	assert.PanicsWithValue(t, "fetch is not declared", func() { _ = metrics.Time("fetch", func() error { return nil }) })
+
+	assertValue(t, 1)(metrics.TestHelper().CounterValue("checkout_calls_total", OutcomeSuccess))
+	assertValue(t, 1)(metrics.TestHelper().CounterValue("parse_calls_total", OutcomeSuccess))
+}
+
+func TestTimeResult(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), Clock: clock})
+
+	value, err := TimeResult(metrics.WithUnit(Milliseconds), "parse", func() (int, error) {
+		clock.Advance(250 * time.Millisecond)
+		return strconv.Atoi("42")
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 42, value)
+
+	_, err = TimeResult(&metrics, "parse_raw", func() (int, error) { return strconv.Atoi("x") })
+	assert.Error(t, err)
+
+	helper := metrics.TestHelper()
+	assert.ElementsMatch(t, []string{"errors", "parse_milliseconds", "parse_calls_total", "parse_raw", "parse_raw_calls_total"}, helper.MetricNames())
+	assertValue(t, 1)(helper.CounterValue("parse_calls_total", OutcomeSuccess))
+	assertValue(t, 1)(helper.CounterValue("parse_raw_calls_total", OutcomeFailure))
+	assertValue(t, 1)(helper.CounterValue("errors", "parse_raw"))
+
+	summary, err := helper.SummarySnapshot("parse_milliseconds")
+	assert.NoError(t, err)
+	assert.Equal(t, 250.0, summary.Sum)
+}
+
+func TestNoopAndFanoutTime(t *testing.T) {
+	calls := 0
+	assert.EqualError(t, NewNoopMetrics().Time("fetch", func() error {
+		calls++
+		return errors.New("no")
+	}), "no")
+	value, err := TimeResult(NewNoopMetrics(), "fetch", func() (string, error) { return "ok", nil })
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", value)
+	assert.Equal(t, 1, calls)
+
+	first := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry()})
+	second := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry()})
+	assert.NoError(t, NewFanoutMetrics(&first, &second).Time("fetch", func() error { return nil }))
+
+	for _, each := range []*PrometheusMetricsImpl{&first, &second} {
+		assertValue(t, 1)(each.TestHelper().CounterValue("fetch_calls_total", OutcomeSuccess))
+	}
+}
@@ -1,48 +1,192 @@
 package api
 
 import (
+	"sync"
 	"time"
-
-	"github.com/prometheus/client_golang/prometheus"
 )
 
-type eventTimer interface {
-	Observe() time.Duration
+// Stopwatch times an event from when the timer was started, observing the time it ran for when stopped. A nil
+// Stopwatch, as returned by no-op metrics, does nothing.
+type Stopwatch struct {
+	clock    Clock
+	start    time.Time
+	observe  func(seconds float64, exemplar Exemplar)
+	lap      func(label string, seconds float64)
+	exemplar Exemplar // used if Stop is given none
+
+	sync.Mutex
+	paused   time.Duration // in total, before any current pause
+	pausedAt time.Duration
+	isPaused bool
+	lastLap  time.Duration
+	finished bool
+	duration time.Duration
+}
+
+// Stop observes the time the stopwatch ran for, i.e. excluding pauses, linked to the trace in any exemplar.
+// Only the first Stop is observed, and none after Cancel; later calls return the same duration.
+func (s *Stopwatch) Stop(exemplars ...Exemplar) time.Duration {
+	if s == nil {
+		return 0
+	}
+	s.Lock()
+	defer s.Unlock()
+
+	if s.finished {
+		return s.duration
+	}
+	s.finished = true
+	s.duration = s.running()
+
+	exemplar := s.exemplar
+	if len(exemplars) > 0 {
+		exemplar = exemplars[0]
+	}
+	s.observe(s.duration.Seconds(), exemplar)
+	return s.duration
+}
+
+// Cancel abandons the measurement, e.g. on a validation failure that would skew the timings, so nothing is
+// observed by later calls to Stop
+func (s *Stopwatch) Cancel() {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.finished = true
 }
 
-type timerFactory interface {
-	NewTimer(o prometheus.Observer) eventTimer
+// Lap observes the time since the previous lap, or the start, as the split labelled lap in the timer's _laps
+// summary, e.g. prefix_checkout_laps{lap="payment"}, and returns it
+func (s *Stopwatch) Lap(label string) time.Duration {
+	if s == nil {
+		return 0
+	}
+	s.Lock()
+	defer s.Unlock()
+
+	if s.finished {
+		return 0
+	}
+	running := s.running()
+	split := running - s.lastLap
+	s.lastLap = running
+	s.lap(label, split.Seconds())
+	return split
 }
 
-type defaultTimer struct {
-	eventTimer
-	timer *prometheus.Timer
+// Pause stops the clock, e.g. around waiting on a lock, until Resume
+func (s *Stopwatch) Pause() {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+
+	if !s.isPaused && !s.finished {
+		s.pausedAt = s.elapsed()
+		s.isPaused = true
+	}
 }
 
-type defaultTimerFactory struct {
-	timerFactory
+func (s *Stopwatch) Resume() {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+
+	if s.isPaused {
+		s.paused += s.elapsed() - s.pausedAt
+		s.isPaused = false
+	}
 }
 
-func (f *defaultTimerFactory) NewTimer(o prometheus.Observer) eventTimer {
-	return &defaultTimer{timer: prometheus.NewTimer(o)}
+// Elapsed returns the time the stopwatch has run for so far, excluding pauses
+func (s *Stopwatch) Elapsed() time.Duration {
+	if s == nil {
+		return 0
+	}
+	s.Lock()
+	defer s.Unlock()
+
+	if s.finished {
+		return s.duration
+	}
+	return s.running()
 }
 
-func (t *defaultTimer) Observe() time.Duration {
-	return t.timer.ObserveDuration()
+// ObserveDuration observes a duration measured elsewhere, e.g. reported by a remote call, in the timer's
+// metric, whatever the state of the stopwatch
+func (s *Stopwatch) ObserveDuration(d time.Duration, exemplars ...Exemplar) {
+	if s == nil {
+		return
+	}
+	s.observe(d.Seconds(), firstExemplar(exemplars))
 }
 
-func (p *PrometheusMetricsImpl) Timer(Name string) func() time.Duration {
-	timer := p.timerFactory.NewTimer(p.Summary(Name).promMetric)
-	return func() time.Duration {
-		diff := timer.Observe()
-		return diff
+func (s *Stopwatch) running() time.Duration {
+	elapsed := s.pausedAt
+	if !s.isPaused {
+		elapsed = s.elapsed()
 	}
+	return elapsed - s.paused
+}
+
+// elapsed includes pauses
+func (s *Stopwatch) elapsed() time.Duration {
+	return s.clock.Now().Sub(s.start)
+}
+
+// Timer starts a stopwatch, observing the time in seconds when stopped
+func (p *PrometheusMetricsImpl) Timer(Name string) *Stopwatch {
+	return p.timer(Name, Unit{})
+}
+
+// timer observes seconds, or another unit of time. Other units are ignored.
+func (p *PrometheusMetricsImpl) timer(Name string, unit Unit) *Stopwatch {
+	unit = p.declaredUnit(Name, unit).orSeconds()
+	summary := p.buildSummary(func(p *PrometheusMetricsImpl, fullMetricName string, fullDescription string) interface{} {
+		return SummaryFacade{metric: p.backend.NewTimer(InstrumentOpts{Name: fullMetricName, Help: fullDescription, Unit: unit.exposed()}), unit: unit}
+	}, unit.suffix(Name), unit.expected(), nil)
+
+	return p.newStopwatch(Name, unit, unit.fromSeconds(summary.metric), nil, nil)
+}
+
+func (p *PrometheusMetricsImpl) TimerWithLabel(Name string, labelName string, labelValue string) *Stopwatch {
+	return p.timerWithLabels(Name, Unit{}, []string{labelName}, []string{labelValue})
+}
+
+func (p *PrometheusMetricsImpl) TimerWithLabels(Name string, labelNames []string, labelValues ...string) *Stopwatch {
+	return p.timerWithLabels(Name, Unit{}, labelNames, labelValues)
 }
 
-func (p *PrometheusMetricsImpl) TimerWithLabel(Name string, labelName string, labelValue string) func() time.Duration {
-	timer := p.timerFactory.NewTimer(p.SummaryWithLabel(Name, labelName).promMetric.WithLabelValues(labelValue))
-	return func() time.Duration {
-		diff := timer.Observe()
-		return diff
+func (p *PrometheusMetricsImpl) timerWithLabels(Name string, unit Unit, labelNames []string, labelValues []string) *Stopwatch {
+	unit = p.declaredUnit(Name, unit).orSeconds()
+	labelNames = p.normaliseLabelNames(labelNames)
+	summary := p.buildLabelledSummary(func(p *PrometheusMetricsImpl, fullMetricName string, fullDescription string) interface{} {
+		return LabelledSummaryFacade{metric: p.backend.NewTimerVec(InstrumentOpts{Name: fullMetricName, Help: fullDescription, LabelNames: labelNames, Unit: unit.exposed()}), unit: unit}
+	}, unit.suffix(Name), unit.expected(), labelNames, nil)
+
+	return p.newStopwatch(Name, unit, unit.fromSeconds(summary.metric.WithLabelValues(labelValues...)), labelNames, labelValues)
+}
+
+// newStopwatch observes into observer, creating the _laps summary, labelled as the timer plus lap, on the first Lap
+func (p *PrometheusMetricsImpl) newStopwatch(Name string, unit Unit, observer ObserverInstrument, labelNames []string, labelValues []string) *Stopwatch {
+	return &Stopwatch{clock: p.clock,
+		start: p.clock.Now(),
+		observe: func(seconds float64, exemplar Exemplar) {
+			observeWithExemplar(observer, seconds, exemplar)
+		},
+		lap: func(label string, seconds float64) {
+			lapLabelNames := append(append([]string(nil), labelNames...), "lap")
+			laps := p.buildLabelledSummary(func(p *PrometheusMetricsImpl, fullMetricName string, fullDescription string) interface{} {
+				return LabelledSummaryFacade{metric: p.backend.NewTimerVec(InstrumentOpts{Name: fullMetricName, Help: fullDescription, LabelNames: lapLabelNames, Unit: unit.exposed()}), unit: unit}
+			}, unit.suffix(Name+"_laps"), unit.expected(), lapLabelNames, nil)
+
+			lapLabelValues := append(append([]string(nil), labelValues...), label)
+			unit.fromSeconds(laps.metric.WithLabelValues(lapLabelValues...)).Observe(seconds)
+		},
 	}
 }
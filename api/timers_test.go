@@ -0,0 +1,113 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newStopwatchTestMetrics() (*PrometheusMetricsImpl, *FakeClock) {
+	clock := NewFakeClock(testStart)
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), Clock: clock})
+	return &metrics, clock
+}
+
+func TestStopwatchPauseAndResume(t *testing.T) {
+	metrics, clock := newStopwatchTestMetrics()
+
+	stopwatch := metrics.Timer("checkout")
+	clock.Advance(time.Second)
+	stopwatch.Pause()
+	clock.Advance(5 * time.Second)
+	assert.Equal(t, time.Second, stopwatch.Elapsed())
+	stopwatch.Resume()
+	clock.Advance(2 * time.Second)
+
+	assert.Equal(t, 3*time.Second, stopwatch.Stop())
+	clock.Advance(time.Second)
+	assert.Equal(t, 3*time.Second, stopwatch.Stop()) // only observed once
+
+	snapshot, err := metrics.TestHelper().SummarySnapshot("checkout")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), snapshot.Count)
+	assert.Equal(t, 3.0, snapshot.Sum)
+}
+
+func TestStopwatchCancel(t *testing.T) {
+	metrics, clock := newStopwatchTestMetrics()
+
+	stopwatch := metrics.TimerWithLabel("checkout", "region", "eu")
+	clock.Advance(time.Second)
+	stopwatch.Cancel()
+	assert.Equal(t, time.Duration(0), stopwatch.Stop())
+	assert.Equal(t, time.Duration(0), stopwatch.Lap("late"))
+
+	snapshot, err := metrics.TestHelper().SummarySnapshot("checkout", "eu")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), snapshot.Count) // created, but nothing observed
+}
+
+func TestStopwatchLaps(t *testing.T) {
+	metrics, clock := newStopwatchTestMetrics()
+
+	stopwatch := metrics.TimerWithLabel("checkout", "region", "eu")
+	clock.Advance(time.Second)
+	assert.Equal(t, time.Second, stopwatch.Lap("basket"))
+	clock.Advance(3 * time.Second)
+	assert.Equal(t, 3*time.Second, stopwatch.Lap("payment"))
+	clock.Advance(time.Second)
+	assert.Equal(t, 5*time.Second, stopwatch.Stop())
+
+	helper := metrics.TestHelper()
+	for lap, expected := range map[string]float64{"basket": 1, "payment": 3} {
+		snapshot, err := helper.SummarySnapshot("checkout_laps", "eu", lap)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, snapshot.Sum, lap)
+	}
+
+	metrics.WithUnit(Milliseconds).Timer("query").Lap("connect")
+	assert.Contains(t, helper.MetricNames(), "query_laps_milliseconds")
+}
+
+func TestStopwatchObserveDuration(t *testing.T) {
+	metrics, _ := newStopwatchTestMetrics()
+
+	stopwatch := metrics.WithUnit(Milliseconds).Timer("remote")
+	stopwatch.ObserveDuration(250 * time.Millisecond)
+	stopwatch.ObserveDuration(750 * time.Millisecond)
+	stopwatch.Cancel()
+
+	snapshot, err := metrics.TestHelper().SummarySnapshot("remote_milliseconds")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), snapshot.Count)
+	assert.Equal(t, 1000.0, snapshot.Sum)
+}
+
+func TestNoopAndFanoutStopwatches(t *testing.T) {
+	noop := NewNoopMetrics().Timer("x")
+	assert.Nil(t, noop)
+	noop.Pause()
+	noop.Resume()
+	noop.Lap("a")
+	noop.ObserveDuration(time.Second)
+	noop.Cancel()
+	assert.Equal(t, time.Duration(0), noop.Stop())
+
+	primary, clock := newStopwatchTestMetrics()
+	recording := NewRecordingBackend()
+	secondary := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), Backend: recording})
+	fanout := NewFanoutMetrics(NewNoopMetrics(), primary, &secondary)
+
+	stopwatch := fanout.Timer("checkout")
+	clock.Advance(2 * time.Second)
+	stopwatch.Lap("basket")
+	assert.Equal(t, 2*time.Second, stopwatch.Stop())
+
+	snapshot, err := primary.TestHelper().SummarySnapshot("checkout")
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, snapshot.Sum)
+	assert.Equal(t, 2.0, recording.SummarySum("checkout"))
+	assert.Equal(t, 2.0, recording.SummarySum("checkout_laps", "basket"))
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Unit is the base unit a metric measures, added to its name as a suffix, e.g. prefix_latency_seconds, and
+// exposed as OpenMetrics UNIT metadata
+type Unit struct {
+	name    string
+	scale   time.Duration // for units of time
+	implied bool          // observed, but not given, so neither added to the name nor exposed
+}
+
+var (
+	Seconds      = Unit{name: "seconds", scale: time.Second}
+	Milliseconds = Unit{name: "milliseconds", scale: time.Millisecond}
+	Bytes        = Unit{name: "bytes"}
+	Ratio        = Unit{name: "ratio"} // between 0 and 1, not a percentage
+)
+
+// CustomUnit returns a unit such as "celsius" or "joules". Prefer base units, e.g. not kilobytes.
+func CustomUnit(name string) Unit {
+	return Unit{name: NormaliseAndLowercaseName(name)}
+}
+
+// unitNamed returns the predefined unit called name, e.g. from a catalogue, or a custom one
+func unitNamed(name string) Unit {
+	for _, each := range []Unit{Seconds, Milliseconds, Bytes, Ratio} {
+		if each.name == name {
+			return each
+		}
+	}
+	if name == "" {
+		return Unit{}
+	}
+	return CustomUnit(name)
+}
+
+func (u Unit) String() string {
+	return u.name
+}
+
+// Suffix adds the unit to name as WithUnit does, for tools that find metrics in source
+func (u Unit) Suffix(name string) string {
+	return u.suffix(name)
+}
+
+// suffix adds the unit to name if not already there, before any _total
+func (u Unit) suffix(name string) string {
+	if u.name == "" || u.implied {
+		return name
+	}
+
+	normalised := NormaliseAndLowercaseName(name)
+	if strings.HasSuffix(strings.TrimSuffix(normalised, "_total"), "_"+u.name) {
+		return name
+	}
+	if strings.HasSuffix(normalised, "_total") {
+		return name[:len(name)-len("_total")] + "_" + u.name + name[len(name)-len("_total"):]
+	}
+	return name + "_" + u.name
+}
+
+// exposed is the unit to give the backend
+func (u Unit) exposed() string {
+	if u.implied {
+		return ""
+	}
+	return u.name
+}
+
+// expected is the unit name linting should expect, including implied seconds
+func (u Unit) expected() string {
+	return u.name
+}
+
+// orSeconds is u if a unit of time, otherwise seconds, as timers observe
+func (u Unit) orSeconds() Unit {
+	if u.scale != 0 {
+		return u
+	}
+	implied := Seconds
+	implied.implied = true
+	return implied
+}
+
+func (u Unit) fromDuration(d time.Duration) float64 {
+	if u.scale == 0 {
+		return d.Seconds()
+	}
+	return float64(d) / float64(u.scale)
+}
+
+// fromSeconds converts the seconds timers observe into u
+func (u Unit) fromSeconds(observer ObserverInstrument) ObserverInstrument {
+	if u.scale == 0 || u.scale == time.Second {
+		return observer
+	}
+	return scaledObserver{observer: observer, factor: float64(time.Second) / float64(u.scale)}
+}
+
+func (u Unit) scaleBuckets(buckets []float64) []float64 {
+	if u.scale == 0 || u.scale == time.Second {
+		return buckets
+	}
+	factor := float64(time.Second) / float64(u.scale)
+	scaled := make([]float64, len(buckets))
+	for i, each := range buckets {
+		scaled[i] = each * factor
+	}
+	return scaled
+}
+
+type scaledObserver struct {
+	observer ObserverInstrument
+	factor   float64
+}
+
+func (o scaledObserver) Observe(value float64) {
+	o.observer.Observe(value * o.factor)
+}
+
+func (o scaledObserver) ObserveWithExemplar(value float64, exemplar prometheus.Labels) {
+	if observer, ok := o.observer.(prometheus.ExemplarObserver); ok {
+		observer.ObserveWithExemplar(value*o.factor, exemplar)
+	} else {
+		o.observer.Observe(value * o.factor)
+	}
+}
+
+// unitMetrics creates every new metric with a unit, sharing everything else with the metrics it came from
+type unitMetrics struct {
+	*PrometheusMetricsImpl
+	unit Unit
+}
+
+// WithUnit returns metrics whose constructors add unit to the name, expose it as metadata, and convert the
+// durations passed to e.g. HistogramFacade.UpdateDuration into it. Timers ignore units other than time.
+func (p *PrometheusMetricsImpl) WithUnit(unit Unit) PrometheusMetrics {
+	return unitMetrics{PrometheusMetricsImpl: p, unit: unit}
+}
+
+func (m unitMetrics) WithUnit(unit Unit) PrometheusMetrics {
+	return unitMetrics{PrometheusMetricsImpl: m.PrometheusMetricsImpl, unit: unit}
+}
+
+func (m unitMetrics) Counter(name string, optionalDesc ...string) CounterFacade {
+	return m.counter(name, m.unit, optionalDesc)
+}
+
+func (m unitMetrics) CounterWithLabel(name string, labelName string, optionalDesc ...string) LabelledCounterFacade {
+	return m.labelledCounter(name, m.unit, []string{labelName}, optionalDesc)
+}
+
+func (m unitMetrics) CounterWithLabels(name string, labelNames []string, optionalDesc ...string) LabelledCounterFacade {
+	return m.labelledCounter(name, m.unit, labelNames, optionalDesc)
+}
+
+func (m unitMetrics) Gauge(name string, optionalDesc ...string) GaugeFacade {
+	return m.gauge(name, m.unit, optionalDesc)
+}
+
+func (m unitMetrics) GaugeWithLabel(name string, labelName string, optionalDesc ...string) LabelledGaugeFacade {
+	return m.labelledGauge(name, m.unit, []string{labelName}, optionalDesc)
+}
+
+func (m unitMetrics) GaugeWithLabels(name string, labelNames []string, optionalDesc ...string) LabelledGaugeFacade {
+	return m.labelledGauge(name, m.unit, labelNames, optionalDesc)
+}
+
+func (m unitMetrics) Histogram(name string, buckets []float64, optionalDesc ...string) HistogramFacade {
+	return m.histogram(name, m.unit, buckets, optionalDesc)
+}
+
+func (m unitMetrics) HistogramForResponseTime(name string, optionalDesc ...string) HistogramFacade {
+	return m.histogramForResponseTime(name, m.unit, optionalDesc)
+}
+
+func (m unitMetrics) Summary(name string, optionalDesc ...string) SummaryFacade {
+	return m.summary(name, m.unit, optionalDesc)
+}
+
+func (m unitMetrics) SummaryWithLabel(name string, labelName string, optionalDesc ...string) LabelledSummaryFacade {
+	return m.labelledSummary(name, m.unit, []string{labelName}, optionalDesc)
+}
+
+func (m unitMetrics) SummaryWithLabels(name string, labelNames []string, optionalDesc ...string) LabelledSummaryFacade {
+	return m.labelledSummary(name, m.unit, labelNames, optionalDesc)
+}
+
+func (m unitMetrics) Timer(Name string) *Stopwatch {
+	return m.timer(Name, m.unit)
+}
+
+func (m unitMetrics) TimerWithLabel(Name string, labelName string, labelValue string) *Stopwatch {
+	return m.timerWithLabels(Name, m.unit, []string{labelName}, []string{labelValue})
+}
+
+func (m unitMetrics) TimerWithLabels(Name string, labelNames []string, labelValues ...string) *Stopwatch {
+	return m.timerWithLabels(Name, m.unit, labelNames, labelValues)
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnits(t *testing.T) {
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc"})
+
+	metrics.WithUnit(Seconds).HistogramForResponseTime("latency").UpdateDuration(250 * time.Millisecond)
+	metrics.WithUnit(Milliseconds).HistogramForResponseTime("db.latency").UpdateDuration(250 * time.Millisecond)
+	metrics.WithUnit(Bytes).Counter("sent_total").IncBy(512)
+	metrics.WithUnit(Bytes).Gauge("heap_bytes").SetValue(1024)
+	metrics.WithUnit(Ratio).GaugeWithLabel("cache_hits", "cache").SetLabels("users").Value(0.5)
+	metrics.WithUnit(Seconds).Counter("cpu").IncByDuration(1500 * time.Millisecond)
+	metrics.WithUnit(Milliseconds).SummaryWithLabel("wait", "queue").ObserveDuration(2*time.Second, "jobs")
+	metrics.Summary("pause").ObserveDuration(2 * time.Second)
+	metrics.HistogramForResponseTime("plain").Update(1)
+
+	assert.ElementsMatch(t, []string{"svc_cache_hits_ratio", "svc_cpu_seconds", "svc_db_latency_milliseconds", "svc_heap_bytes",
+		"svc_latency_seconds", "svc_pause", "svc_plain", "svc_sent_bytes_total", "svc_wait_milliseconds"}, metrics.TestHelper().MetricNames())
+
+	helper := metrics.TestHelper()
+	assertValue(t, 1.5)(helper.CounterValue("cpu_seconds"))
+	assertValue(t, 512)(helper.CounterValue("sent_bytes_total"))
+
+	snapshot, err := helper.HistogramSnapshot("db_latency_milliseconds")
+	assert.NoError(t, err)
+	assert.Equal(t, 250.0, snapshot.Sum)
+	assert.Equal(t, uint64(1), snapshot.Buckets[250]) // DefaultBuckets scaled, 0.25s becoming 250ms
+
+	summary, err := helper.SummarySnapshot("wait_milliseconds", "jobs")
+	assert.NoError(t, err)
+	assert.Equal(t, 2000.0, summary.Sum)
+
+	summary, err = helper.SummarySnapshot("pause")
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, summary.Sum)
+
+	exposition := openMetrics(t, &metrics)
+	assert.Contains(t, exposition, "# UNIT svc_latency_seconds seconds\n")
+	assert.Contains(t, exposition, "# UNIT svc_sent_bytes bytes\n")
+	assert.Contains(t, exposition, "# UNIT svc_cache_hits_ratio ratio\n")
+	assert.NotContains(t, exposition, "# UNIT svc_plain")
+	assert.NotContains(t, exposition, "# UNIT svc_pause")
+}
+
+func TestTimerUnits(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	metrics := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry(), Clock: clock})
+
+	timedFor(clock, 1500*time.Millisecond, metrics.WithUnit(Milliseconds).Timer("query"))
+	timedFor(clock, 1500*time.Millisecond, metrics.WithUnit(Milliseconds).TimerWithLabel("call", "api", "users"))
+	timedFor(clock, 1500*time.Millisecond, metrics.WithUnit(Bytes).Timer("misused")) // not a unit of time, so seconds
+	timedFor(clock, 1500*time.Millisecond, metrics.Timer("plain"))
+
+	helper := metrics.TestHelper()
+	assert.ElementsMatch(t, []string{"call_milliseconds", "misused", "plain", "query_milliseconds"}, helper.MetricNames())
+
+	for name, expected := range map[string]float64{"query_milliseconds": 1500, "misused": 1.5, "plain": 1.5} {
+		summary, err := helper.SummarySnapshot(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, summary.Sum, name)
+	}
+
+	exposition := openMetrics(t, &metrics)
+	assert.Contains(t, exposition, "# UNIT query_milliseconds milliseconds\n")
+	assert.Contains(t, exposition, "# UNIT call_milliseconds milliseconds\n")
+	assert.NotContains(t, exposition, "# UNIT misused")
+
+	units := map[string]string{}
+	for _, each := range metrics.Catalogue().Metrics {
+		units[each.Name] = each.Unit
+	}
+	assert.Equal(t, map[string]string{"call_milliseconds": "milliseconds", "misused": "seconds", "plain": "seconds", "query_milliseconds": "milliseconds"}, units)
+}
+
+func TestNoopAndFanoutUnits(t *testing.T) {
+	NewNoopMetrics().WithUnit(Bytes).Counter("sent").Inc()
+
+	first := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry()})
+	second := NewMetrics(MetricOpts{Registry: prometheus.NewRegistry()})
+	NewFanoutMetrics(&first, &second).WithUnit(Bytes).Counter("sent_total").Inc()
+
+	assert.Equal(t, []string{"sent_bytes_total"}, first.TestHelper().MetricNames())
+	assert.Equal(t, []string{"sent_bytes_total"}, second.TestHelper().MetricNames())
+}
+
+func openMetrics(t *testing.T, metrics *PrometheusMetricsImpl) string {
+	var buf bytes.Buffer
+	encoder := expfmt.NewEncoder(&buf, expfmt.NewFormat(expfmt.TypeOpenMetrics))
+	for _, each := range metrics.gatherOK(t) {
+		assert.NoError(t, encoder.Encode(each))
+	}
+	return buf.String()
+}
@@ -24,6 +24,6 @@ func main() {
 }
 
 func timedMethod(metrics promenade.PrometheusMetrics) {
-	defer metrics.Timer("t")()
+	defer metrics.Timer("t").Stop()
 	fmt.Println("Whatever it is we're timing")
 }
@@ -0,0 +1,11 @@
+// Command promenade-vet runs the promenadecheck Analyzer, standalone or as go vet -vettool=$(which promenade-vet)
+package main
+
+import (
+	"github.com/poblish/promenade/promenadecheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(promenadecheck.Analyzer)
+}
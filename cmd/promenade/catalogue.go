@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/poblish/promenade/api"
+	"github.com/poblish/promenade/internal/apicalls"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.yaml.in/yaml/v3"
+	"golang.org/x/tools/go/packages"
+)
+
+type catalogueOpts struct {
+	prefix        string
+	separator     string
+	caseSensitive bool
+	json          bool
+}
+
+// names resolves names exactly as the constructors would
+func (opts catalogueOpts) names() *api.TestHelper {
+	metrics := api.NewMetrics(api.MetricOpts{Registry: prometheus.NewRegistry(),
+		MetricNamePrefix:         opts.prefix,
+		PrefixSeparator:          opts.separator,
+		CaseSensitiveMetricNames: opts.caseSensitive,
+		Backend:                  api.NewNoopBackend()})
+	return metrics.TestHelper()
+}
+
+// exposed returns the catalogue with the names given in code resolved to those exposed, and the prefix used
+func (opts catalogueOpts) exposed(catalogue *api.Catalogue) (*api.Catalogue, string) {
+	helper := opts.names()
+	resolved := &api.Catalogue{}
+	for _, each := range catalogue.Metrics {
+		each.Name = helper.MetricName(each.NameWithUnit())
+		resolved.Metrics = append(resolved.Metrics, each)
+	}
+	return resolved, helper.MetricName("")
+}
+
+func runCatalogue(args []string) error {
+	var opts catalogueOpts
+	flags := flag.NewFlagSet("catalogue", flag.ExitOnError)
+	flags.StringVar(&opts.prefix, "prefix", "", "MetricNamePrefix, as given to NewMetrics. Omit to load the output with NewMetricsFromCatalogue")
+	flags.StringVar(&opts.separator, "separator", "", "PrefixSeparator, as given to NewMetrics")
+	flags.BoolVar(&opts.caseSensitive, "case-sensitive", false, "CaseSensitiveMetricNames, as given to NewMetrics")
+	flags.BoolVar(&opts.json, "json", false, "print JSON rather than YAML")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: promenade catalogue [flags] [packages]")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	catalogue, err := scanCatalogue(patterns, opts, os.Stderr)
+	if err != nil {
+		return err
+	}
+	return writeCatalogue(os.Stdout, catalogue, opts.json)
+}
+
+// scanCatalogue loads the packages and declares every metric they create with constant names, warning about
+// the rest, and about names used with more than one type
+func scanCatalogue(patterns []string, opts catalogueOpts, warnings io.Writer) (*api.Catalogue, error) {
+	config := &packages.Config{Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo}
+	pkgs, err := packages.Load(config, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return nil, fmt.Errorf("could not load packages")
+	}
+
+	helper := opts.names()
+
+	definitions := make(map[string]*api.MetricDefinition)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			scanFile(pkg.Fset, pkg.TypesInfo, file, helper, definitions, warnings)
+		}
+	}
+
+	catalogue := &api.Catalogue{}
+	for _, each := range definitions {
+		catalogue.Metrics = append(catalogue.Metrics, *each)
+	}
+	sort.Slice(catalogue.Metrics, func(i, j int) bool { return catalogue.Metrics[i].Name < catalogue.Metrics[j].Name })
+	return catalogue, nil
+}
+
+func scanFile(fset *token.FileSet, info *types.Info, file *ast.File, helper *api.TestHelper, definitions map[string]*api.MetricDefinition, warnings io.Writer) {
+	apicalls.Find(info, file, func(call apicalls.Call) {
+		position := fset.Position(call.Expr.Pos())
+		if !call.NameKnown {
+			fmt.Fprintf(warnings, "%s: skipping %s with a non-constant name\n", position, call.Method)
+			return
+		}
+
+		if !call.LabelsKnown {
+			fmt.Fprintf(warnings, "%s: label names of %s are not constant, so are left out\n", position, call.Name)
+		}
+
+		name := helper.MetricName(call.Name)
+
+		existing, ok := definitions[name]
+		if !ok {
+			definitions[name] = &api.MetricDefinition{Name: name, Help: call.Help, Type: call.Type, Labels: call.Labels, Buckets: call.Buckets, Unit: call.Unit}
+			return
+		}
+
+		if existing.Type != call.Type {
+			fmt.Fprintf(warnings, "%s: %s is already a %s, not a %s\n", position, name, existing.Type, call.Type)
+			return
+		}
+		if existing.Help == "" {
+			existing.Help = call.Help
+		}
+		if existing.Labels == nil {
+			existing.Labels = call.Labels
+		}
+		if existing.Buckets == nil {
+			existing.Buckets = call.Buckets
+		}
+	})
+}
+
+func writeCatalogue(out io.Writer, catalogue *api.Catalogue, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(catalogue)
+	}
+
+	encoder := yaml.NewEncoder(out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(catalogue); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/poblish/promenade/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanCatalogue(t *testing.T) {
+	var warnings bytes.Buffer
+	catalogue, err := scanCatalogue([]string{"./testdata/sample"}, catalogueOpts{prefix: "svc"}, &warnings)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []api.MetricDefinition{
+		{Name: "svc_ages", Type: "summary", Labels: []string{"city"}},
+		{Name: "svc_errors", Type: "counter", Labels: []string{"error_type"}},
+		{Name: "svc_fetch", Type: "timer"},
+		{Name: "svc_fetch_calls_total", Type: "counter", Labels: []string{"outcome"}},
+		{Name: "svc_http_requests", Type: "counter", Help: "Requests served", Labels: []string{"method"}},
+		{Name: "svc_latency", Type: "histogram"},
+		{Name: "svc_load", Type: "timer"},
+		{Name: "svc_load_calls_total", Type: "counter", Labels: []string{"outcome"}},
+		{Name: "svc_misused", Type: "timer"},
+		{Name: "svc_parse_calls_total", Type: "counter", Labels: []string{"outcome"}},
+		{Name: "svc_parse_milliseconds", Type: "timer", Unit: "milliseconds"},
+		{Name: "svc_payload", Type: "histogram", Help: "Payload size", Buckets: []float64{100, 1000, 10000}},
+		{Name: "svc_query_milliseconds", Type: "timer", Unit: "milliseconds"},
+		{Name: "svc_queue", Type: "gauge", Labels: []string{"priority", "region"}},
+		{Name: "svc_render", Type: "timer", Labels: []string{"tenant", "route"}},
+		{Name: "svc_renders_total", Type: "counter"},
+		{Name: "svc_sent_bytes_total", Type: "counter", Unit: "bytes"},
+		{Name: "svc_temperature_celsius", Type: "gauge", Unit: "celsius"},
+		{Name: "svc_work", Type: "timer", Labels: []string{"kind"}},
+	}, catalogue.Metrics)
+
+	assert.Regexp(t, `sample.go:21:2: skipping Counter with a non-constant name
+.*sample.go:22:2: skipping Gauge with a non-constant name
+.*sample.go:23:2: svc_http_requests is already a counter, not a gauge
+`, warnings.String())
+}
+
+func TestWriteCatalogue(t *testing.T) {
+	catalogue := &api.Catalogue{Metrics: []api.MetricDefinition{{Name: "queue", Type: "gauge", Labels: []string{"priority"}}}}
+
+	var yaml bytes.Buffer
+	assert.NoError(t, writeCatalogue(&yaml, catalogue, false))
+	assert.Equal(t, "metrics:\n  - name: queue\n    type: gauge\n    labels:\n      - priority\n", yaml.String())
+
+	parsed, err := api.ParseCatalogue(yaml.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, catalogue, parsed)
+
+	var json bytes.Buffer
+	assert.NoError(t, writeCatalogue(&json, catalogue, true))
+	parsed, err = api.ParseCatalogue(json.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, catalogue, parsed)
+}
+
+func TestExposedCatalogue(t *testing.T) {
+	catalogue := &api.Catalogue{Metrics: []api.MetricDefinition{{Name: "Queue Size", Type: "gauge"}}}
+
+	exposed, prefix := catalogueOpts{prefix: "svc"}.exposed(catalogue)
+	assert.Equal(t, "svc_", prefix)
+	assert.Equal(t, []api.MetricDefinition{{Name: "svc_queue_size", Type: "gauge"}}, exposed.Metrics)
+
+	exposed, prefix = catalogueOpts{}.exposed(catalogue)
+	assert.Equal(t, "", prefix)
+	assert.Equal(t, "queue_size", exposed.Metrics[0].Name)
+	assert.Equal(t, "Queue Size", catalogue.Metrics[0].Name)
+}
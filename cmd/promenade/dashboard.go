@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/poblish/promenade/api"
+	"github.com/poblish/promenade/grafana"
+)
+
+func runDashboard(args []string) error {
+	var opts catalogueOpts
+	var dashboardOpts grafana.Opts
+	flags := flag.NewFlagSet("dashboard", flag.ExitOnError)
+	flags.StringVar(&opts.prefix, "prefix", "", "MetricNamePrefix, as given to NewMetrics, if the catalogue's names don't include it")
+	flags.StringVar(&opts.separator, "separator", "", "PrefixSeparator, as given to NewMetrics")
+	flags.BoolVar(&opts.caseSensitive, "case-sensitive", false, "CaseSensitiveMetricNames, as given to NewMetrics")
+	flags.StringVar(&dashboardOpts.Title, "title", "", "dashboard title")
+	flags.StringVar(&dashboardOpts.UID, "uid", "", "dashboard UID, to update an existing dashboard")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: promenade dashboard [flags] catalogue.yaml")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	catalogue, err := api.LoadCatalogue(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	exposed, prefix := opts.exposed(catalogue)
+	dashboardOpts.Prefix = prefix
+
+	data, err := grafana.NewDashboard(exposed, dashboardOpts).JSON()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Println(string(data))
+	return err
+}
@@ -0,0 +1,44 @@
+// Command promenade provides tools for codebases using promenade metrics
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type command struct {
+	run     func(args []string) error
+	summary string
+}
+
+var commands = map[string]command{
+	"catalogue": {runCatalogue, "scan Go packages for metrics, and print a catalogue of them"},
+	"dashboard": {runDashboard, "print a Grafana dashboard for the metrics in a catalogue"},
+	"rules":     {runRules, "print Prometheus recording and alerting rules for the metrics in a catalogue"},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "promenade:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: promenade <command> [flags] [args]")
+	fmt.Fprintln(os.Stderr)
+	for name, each := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, each.summary)
+	}
+}
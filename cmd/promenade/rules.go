@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/poblish/promenade/api"
+	"github.com/poblish/promenade/rules"
+	"go.yaml.in/yaml/v3"
+)
+
+func runRules(args []string) error {
+	var opts catalogueOpts
+	var rulesOpts rules.Opts
+	var overrides, aggregateBy string
+	flags := flag.NewFlagSet("rules", flag.ExitOnError)
+	flags.StringVar(&opts.prefix, "prefix", "", "MetricNamePrefix, as given to NewMetrics, if the catalogue's names don't include it")
+	flags.StringVar(&opts.separator, "separator", "", "PrefixSeparator, as given to NewMetrics")
+	flags.BoolVar(&opts.caseSensitive, "case-sensitive", false, "CaseSensitiveMetricNames, as given to NewMetrics")
+	flags.StringVar(&rulesOpts.GroupName, "group", "", "rule group name")
+	flags.StringVar(&rulesOpts.Window, "window", "", "rate window")
+	flags.Float64Var(&rulesOpts.ErrorRate, "error-rate", 0, "errors per second, per error type, to alert on")
+	flags.Float64Var(&rulesOpts.LatencySLO, "latency-slo", 0, "seconds, above which histograms and timers alert")
+	flags.StringVar(&aggregateBy, "aggregate-by", "", "comma-separated labels kept by every sum and max, default is job")
+	flags.StringVar(&overrides, "overrides", "", "YAML file of per-metric overrides, keyed by exposed metric name")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: promenade rules [flags] catalogue.yaml")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if aggregateBy != "" {
+		rulesOpts.AggregateBy = strings.Split(aggregateBy, ",")
+	}
+
+	catalogue, err := api.LoadCatalogue(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if overrides != "" {
+		data, err := os.ReadFile(overrides)
+		if err != nil {
+			return err
+		}
+		if err := yaml.Unmarshal(data, &rulesOpts.Overrides); err != nil {
+			return fmt.Errorf("%s: %w", overrides, err)
+		}
+	}
+
+	exposed, _ := opts.exposed(catalogue)
+
+	data, err := rules.NewRules(exposed, rulesOpts).YAML()
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
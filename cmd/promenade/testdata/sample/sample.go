@@ -0,0 +1,40 @@
+package sample
+
+import (
+	"context"
+
+	"github.com/poblish/promenade/api"
+)
+
+const requestsName = "HTTP Requests"
+
+func handle(metrics api.PrometheusMetrics, impl *api.PrometheusMetricsImpl, dynamic string) {
+	metrics.CounterWithLabel(requestsName, "method").IncLabel("GET")
+	metrics.CounterWithLabel("http-requests", "method", "Requests served").IncLabel("POST")
+	metrics.GaugeWithLabels("queue", []string{"priority", "region"}).IncLabels("high", "eu")
+	impl.Histogram("payload", []float64{100, 1e3, 1e4}, "Payload size")
+	impl.HistogramForResponseTime("latency")
+	metrics.SummaryWithLabel("ages", "city")
+	metrics.Error("timeout")
+	defer metrics.TimerWithLabel("work", "kind", dynamic).Stop()
+
+	metrics.Counter(dynamic)
+	metrics.Gauge("requests_" + dynamic)
+	metrics.Gauge("http requests")
+	metrics.Time("fetch", func() error { return nil })
+	metrics.WithUnit(api.Bytes).Counter("sent_total")
+	metrics.WithUnit(api.Milliseconds).Timer("query")
+	metrics.WithUnit(api.Bytes).Timer("misused")
+	metrics.WithUnit(api.CustomUnit("celsius")).Gauge("temperature")
+}
+
+func handleCtx(ctx context.Context) {
+	defer api.TimerCtx(ctx, "render", "tenant", "route").Stop()
+	api.IncCtx(ctx, "renders_total")
+	api.ErrorCtx(ctx, "render")
+}
+
+func handleResult(metrics api.PrometheusMetrics) {
+	api.TimeResult(metrics.WithUnit(api.Milliseconds), "parse", func() (int, error) { return 0, nil })
+	api.TimeResult[string](metrics, "load", func() (string, error) { return "", nil })
+}
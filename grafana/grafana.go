@@ -0,0 +1,238 @@
+// Package grafana generates Grafana dashboards for promenade metrics, from a catalogue or the metrics registered
+// at runtime, with a row of panels for each subsystem
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/poblish/promenade/api"
+)
+
+type Opts struct {
+	Title     string    // default is "Metrics"
+	UID       string    // optional, for updating an existing dashboard
+	Prefix    string    // the MetricNamePrefix with its separator, ignored when grouping by subsystem
+	Quantiles []float64 // shown for histograms and summaries, default is 0.5, 0.9 and 0.99
+}
+
+type Dashboard struct {
+	UID           string     `json:"uid,omitempty"`
+	Title         string     `json:"title"`
+	Tags          []string   `json:"tags"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Time          TimeRange  `json:"time"`
+	Refresh       string     `json:"refresh"`
+	Templating    Templating `json:"templating"`
+	Panels        []Panel    `json:"panels"`
+}
+
+type TimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type Templating struct {
+	List []Variable `json:"list"`
+}
+
+type Variable struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Type  string `json:"type"`
+	Query string `json:"query"`
+}
+
+type Panel struct {
+	ID          int          `json:"id"`
+	Type        string       `json:"type"`
+	Title       string       `json:"title"`
+	Description string       `json:"description,omitempty"`
+	GridPos     GridPos      `json:"gridPos"`
+	Collapsed   *bool        `json:"collapsed,omitempty"`
+	Datasource  *Datasource  `json:"datasource,omitempty"`
+	Targets     []Target     `json:"targets,omitempty"`
+	FieldConfig *FieldConfig `json:"fieldConfig,omitempty"`
+}
+
+type GridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type Datasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type Target struct {
+	RefID        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat,omitempty"`
+}
+
+type FieldConfig struct {
+	Defaults FieldDefaults `json:"defaults"`
+}
+
+type FieldDefaults struct {
+	Unit string `json:"unit,omitempty"`
+}
+
+const (
+	panelWidth    = 12
+	panelHeight   = 8
+	defaultGroup  = "general"
+	rateSelection = "[$__rate_interval]"
+)
+
+var defaultQuantiles = []float64{0.5, 0.9, 0.99}
+
+var datasource = &Datasource{Type: "prometheus", UID: "${datasource}"}
+
+// units maps catalogue units to Grafana's
+var units = map[string]string{
+	"seconds":      "s",
+	"milliseconds": "ms",
+	"bytes":        "bytes",
+	"ratio":        "percentunit",
+}
+
+// NewDashboard returns a dashboard with a panel for each metric in the catalogue: the per-second rate for
+// counters, the current value for gauges, and quantiles for histograms, summaries and timers. Metric names in
+// the catalogue must be as exposed, e.g. from PrometheusMetricsImpl.Catalogue().
+func NewDashboard(catalogue *api.Catalogue, opts Opts) Dashboard {
+	if opts.Title == "" {
+		opts.Title = "Metrics"
+	}
+	if len(opts.Quantiles) == 0 {
+		opts.Quantiles = defaultQuantiles
+	}
+
+	dashboard := Dashboard{UID: opts.UID,
+		Title:         opts.Title,
+		Tags:          []string{"promenade"},
+		SchemaVersion: 39,
+		Time:          TimeRange{From: "now-6h", To: "now"},
+		Refresh:       "1m",
+		Templating:    Templating{List: []Variable{{Name: "datasource", Label: "Data source", Type: "datasource", Query: "prometheus"}}},
+		Panels:        []Panel{},
+	}
+
+	groups := make(map[string][]api.MetricDefinition)
+	for _, each := range catalogue.Metrics {
+		group := subsystem(each.Name, opts.Prefix)
+		groups[group] = append(groups[group], each)
+	}
+
+	groupNames := make([]string, 0, len(groups))
+	for name := range groups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+
+	id, y := 1, 0
+	for _, group := range groupNames {
+		collapsed := false
+		dashboard.Panels = append(dashboard.Panels, Panel{ID: id, Type: "row", Title: group, Collapsed: &collapsed, GridPos: GridPos{H: 1, W: 24, Y: y}})
+		id++
+		y++
+
+		metrics := groups[group]
+		sort.Slice(metrics, func(i, j int) bool { return metrics[i].Name < metrics[j].Name })
+
+		for i, each := range metrics {
+			panel := newPanel(each, opts.Quantiles)
+			panel.ID = id
+			panel.GridPos = GridPos{H: panelHeight, W: panelWidth, X: (i % 2) * panelWidth, Y: y + (i/2)*panelHeight}
+			dashboard.Panels = append(dashboard.Panels, panel)
+			id++
+		}
+		y += ((len(metrics) + 1) / 2) * panelHeight
+	}
+	return dashboard
+}
+
+// NewDashboardForMetrics returns a dashboard for every metric created so far, e.g. once a service has started
+func NewDashboardForMetrics(metrics *api.PrometheusMetricsImpl, opts Opts) Dashboard {
+	if opts.Prefix == "" {
+		opts.Prefix = metrics.TestHelper().MetricName("")
+	}
+	return NewDashboard(metrics.Catalogue(), opts)
+}
+
+// JSON returns the dashboard as Grafana expects to import it
+func (d Dashboard) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// subsystem is the first part of the name after the prefix, e.g. http for prefix_http_requests_total
+func subsystem(name string, prefix string) string {
+	name = strings.TrimPrefix(name, prefix)
+	if index := strings.Index(name, "_"); index > 0 {
+		return name[:index]
+	}
+	return defaultGroup
+}
+
+func newPanel(metric api.MetricDefinition, quantiles []float64) Panel {
+	panel := Panel{Type: "timeseries", Title: metric.Name, Description: metric.Help, Datasource: datasource}
+	if unit, ok := units[metric.Unit]; ok {
+		panel.FieldConfig = &FieldConfig{Defaults: FieldDefaults{Unit: unit}}
+	}
+
+	byLabels := ""
+	legend := legendFormat(metric.Labels)
+	if len(metric.Labels) > 0 {
+		byLabels = " by (" + strings.Join(metric.Labels, ", ") + ")"
+	}
+
+	switch metric.Type {
+	case api.CatalogueCounter:
+		panel.Title += " per second"
+		panel.Targets = []Target{{RefID: "A", Expr: fmt.Sprintf("sum%s (rate(%s%s))", byLabels, metric.Name, rateSelection), LegendFormat: legend}}
+		if panel.FieldConfig == nil {
+			panel.FieldConfig = &FieldConfig{Defaults: FieldDefaults{Unit: "ops"}}
+		}
+	case api.CatalogueGauge:
+		panel.Type = "stat"
+		panel.Targets = []Target{{RefID: "A", Expr: metric.Name, LegendFormat: legend}}
+	case api.CatalogueHistogram:
+		for i, quantile := range quantiles {
+			expr := fmt.Sprintf("histogram_quantile(%v, sum by (%s) (rate(%s_bucket%s)))", quantile, strings.Join(append([]string{"le"}, metric.Labels...), ", "), metric.Name, rateSelection)
+			panel.Targets = append(panel.Targets, Target{RefID: refID(i), Expr: expr, LegendFormat: quantileLegend(quantile, legend)})
+		}
+	case api.CatalogueSummary, api.CatalogueTimer:
+		for i, quantile := range quantiles {
+			expr := fmt.Sprintf("%s{quantile=\"%v\"}", metric.Name, quantile)
+			panel.Targets = append(panel.Targets, Target{RefID: refID(i), Expr: expr, LegendFormat: quantileLegend(quantile, legend)})
+		}
+		if metric.Type == api.CatalogueTimer && panel.FieldConfig == nil {
+			panel.FieldConfig = &FieldConfig{Defaults: FieldDefaults{Unit: "s"}}
+		}
+	}
+	return panel
+}
+
+func legendFormat(labels []string) string {
+	parts := make([]string, len(labels))
+	for i, label := range labels {
+		parts[i] = "{{" + label + "}}"
+	}
+	return strings.Join(parts, " ")
+}
+
+func quantileLegend(quantile float64, legend string) string {
+	percentile := strconv.FormatFloat(math.Round(quantile*1e6)/1e4, 'f', -1, 64) // e.g. not 99.00000000000001
+	return strings.TrimSpace("p" + percentile + " " + legend)
+}
+
+func refID(index int) string {
+	return string(rune('A' + index))
+}
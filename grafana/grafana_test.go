@@ -0,0 +1,86 @@
+package grafana
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/poblish/promenade/api"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDashboardForMetrics(t *testing.T) {
+	metrics := api.NewMetrics(api.MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc"})
+	metrics.CounterWithLabel("http.requests", "method", "Requests served")
+	metrics.Gauge("http.connections")
+	metrics.HistogramForResponseTime("db_latency")
+	metrics.TimerWithLabel("db.query", "table", "users")
+	metrics.Summary("sizes")
+	metrics.Error("oops")
+
+	dashboard := NewDashboardForMetrics(&metrics, Opts{Title: "Service", UID: "svc"})
+	assert.Equal(t, "Service", dashboard.Title)
+	assert.Equal(t, "svc", dashboard.UID)
+
+	type summary struct {
+		Type  string
+		Title string
+		Pos   GridPos
+		Exprs []string
+	}
+	var panels []summary
+	for _, each := range dashboard.Panels {
+		panel := summary{Type: each.Type, Title: each.Title, Pos: each.GridPos}
+		for _, target := range each.Targets {
+			panel.Exprs = append(panel.Exprs, target.Expr)
+		}
+		panels = append(panels, panel)
+	}
+
+	assert.Equal(t, []summary{
+		{Type: "row", Title: "db", Pos: GridPos{H: 1, W: 24}},
+		{Type: "timeseries", Title: "svc_db_latency", Pos: GridPos{H: 8, W: 12, Y: 1}, Exprs: []string{
+			"histogram_quantile(0.5, sum by (le) (rate(svc_db_latency_bucket[$__rate_interval])))",
+			"histogram_quantile(0.9, sum by (le) (rate(svc_db_latency_bucket[$__rate_interval])))",
+			"histogram_quantile(0.99, sum by (le) (rate(svc_db_latency_bucket[$__rate_interval])))"}},
+		{Type: "timeseries", Title: "svc_db_query", Pos: GridPos{H: 8, W: 12, X: 12, Y: 1}, Exprs: []string{
+			`svc_db_query{quantile="0.5"}`, `svc_db_query{quantile="0.9"}`, `svc_db_query{quantile="0.99"}`}},
+		{Type: "row", Title: "general", Pos: GridPos{H: 1, W: 24, Y: 9}},
+		{Type: "timeseries", Title: "svc_errors per second", Pos: GridPos{H: 8, W: 12, Y: 10}, Exprs: []string{
+			"sum by (error_type) (rate(svc_errors[$__rate_interval]))"}},
+		{Type: "timeseries", Title: "svc_sizes", Pos: GridPos{H: 8, W: 12, X: 12, Y: 10}, Exprs: []string{
+			`svc_sizes{quantile="0.5"}`, `svc_sizes{quantile="0.9"}`, `svc_sizes{quantile="0.99"}`}},
+		{Type: "row", Title: "http", Pos: GridPos{H: 1, W: 24, Y: 18}},
+		{Type: "stat", Title: "svc_http_connections", Pos: GridPos{H: 8, W: 12, Y: 19}, Exprs: []string{"svc_http_connections"}},
+		{Type: "timeseries", Title: "svc_http_requests per second", Pos: GridPos{H: 8, W: 12, X: 12, Y: 19}, Exprs: []string{
+			"sum by (method) (rate(svc_http_requests[$__rate_interval]))"}},
+	}, panels)
+
+	assert.Equal(t, "p50 {{table}}", dashboard.Panels[2].Targets[0].LegendFormat)
+	assert.Equal(t, "p99 {{table}}", dashboard.Panels[2].Targets[2].LegendFormat)
+	assert.Equal(t, "Requests served", dashboard.Panels[8].Description)
+}
+
+func TestDashboardFromCatalogue(t *testing.T) {
+	catalogue := &api.Catalogue{Metrics: []api.MetricDefinition{
+		{Name: "payload", Type: "histogram", Unit: "bytes"},
+		{Name: "calc", Type: "timer"},
+		{Name: "jobs_total", Type: "counter"},
+	}}
+
+	dashboard := NewDashboard(catalogue, Opts{Quantiles: []float64{0.999}})
+	assert.Equal(t, "Metrics", dashboard.Title)
+	assert.Equal(t, "bytes", dashboard.Panels[2].FieldConfig.Defaults.Unit)
+	assert.Equal(t, "s", dashboard.Panels[1].FieldConfig.Defaults.Unit)
+	assert.Equal(t, []Target{{RefID: "A", Expr: `calc{quantile="0.999"}`, LegendFormat: "p99.9"}}, dashboard.Panels[1].Targets)
+	assert.Equal(t, "jobs", dashboard.Panels[3].Title)
+	assert.Equal(t, []Target{{RefID: "A", Expr: "sum (rate(jobs_total[$__rate_interval]))"}}, dashboard.Panels[4].Targets)
+
+	data, err := dashboard.JSON()
+	assert.NoError(t, err)
+
+	var parsed map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &parsed))
+	assert.Equal(t, float64(39), parsed["schemaVersion"])
+	assert.Equal(t, 5, len(parsed["panels"].([]interface{})))
+}
@@ -0,0 +1,328 @@
+// Package apicalls recognises calls that create metrics through the promenade API, for static tools
+package apicalls
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+
+	"github.com/poblish/promenade/api"
+)
+
+const apiPath = "github.com/poblish/promenade/api"
+
+// Call is a call that creates, or looks up, a metric. Name, Labels, Help and Buckets are only known when given
+// as constants or literals; NameKnown and LabelsKnown say whether they were.
+type Call struct {
+	Expr        *ast.CallExpr
+	Method      string
+	Type        string // as in api.MetricDefinition
+	Labelled    bool   // created by one of the WithLabel(s) methods, or Error
+	Name        string // with any unit given by WithUnit, as exposed
+	NameKnown   bool
+	Unit        string
+	Labels      []string
+	LabelsKnown bool
+	Help        string
+	Buckets     []float64
+}
+
+type argPositions struct {
+	metricType string
+	timed      bool // only takes units of time, observing seconds otherwise
+	labels     int  // index of the label name(s) argument, or -1
+	desc       int  // index of the optional description, or -1
+	buckets    int  // index of the buckets argument, or -1
+}
+
+var methods = map[string]argPositions{
+	"Counter":                  {metricType: api.CatalogueCounter, labels: -1, desc: 1, buckets: -1},
+	"CounterWithLabel":         {metricType: api.CatalogueCounter, labels: 1, desc: 2, buckets: -1},
+	"CounterWithLabels":        {metricType: api.CatalogueCounter, labels: 1, desc: 2, buckets: -1},
+	"Gauge":                    {metricType: api.CatalogueGauge, labels: -1, desc: 1, buckets: -1},
+	"GaugeWithLabel":           {metricType: api.CatalogueGauge, labels: 1, desc: 2, buckets: -1},
+	"GaugeWithLabels":          {metricType: api.CatalogueGauge, labels: 1, desc: 2, buckets: -1},
+	"Histogram":                {metricType: api.CatalogueHistogram, labels: -1, desc: 2, buckets: 1},
+	"HistogramForResponseTime": {metricType: api.CatalogueHistogram, timed: true, labels: -1, desc: 1, buckets: -1},
+	"Summary":                  {metricType: api.CatalogueSummary, labels: -1, desc: 1, buckets: -1},
+	"SummaryWithLabel":         {metricType: api.CatalogueSummary, labels: 1, desc: 2, buckets: -1},
+	"SummaryWithLabels":        {metricType: api.CatalogueSummary, labels: 1, desc: 2, buckets: -1},
+	"Timer":                    {metricType: api.CatalogueTimer, timed: true, labels: -1, desc: -1, buckets: -1},
+	"TimerWithLabel":           {metricType: api.CatalogueTimer, timed: true, labels: 1, desc: -1, buckets: -1},
+	"TimerWithLabels":          {metricType: api.CatalogueTimer, timed: true, labels: 1, desc: -1, buckets: -1},
+}
+
+// units are those predefined by the API, by name
+var units = map[string]api.Unit{"Seconds": api.Seconds, "Milliseconds": api.Milliseconds, "Bytes": api.Bytes, "Ratio": api.Ratio}
+
+// contextFunctions create metrics from the metrics in a context, with label names from the third argument on
+var contextFunctions = map[string]string{"IncCtx": api.CatalogueCounter, "TimerCtx": api.CatalogueTimer}
+
+// Find calls fn for each metric-creating call in file, including Error, which uses the shared "errors" counter,
+// Time and TimeResult, which create a timer and counter, and may use it, and the Ctx functions
+func Find(info *types.Info, file *ast.File, fn func(Call)) {
+	ast.Inspect(file, func(node ast.Node) bool {
+		expr, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		if function, ok := APIFunction(info, expr); ok {
+			findFunction(info, expr, function, fn)
+			return true
+		}
+
+		method, ok := APIMethod(info, expr, "PrometheusMetrics", "PrometheusMetricsImpl")
+		if !ok {
+			return true
+		}
+
+		if method == "Error" {
+			fn(Call{Expr: expr, Method: method, Type: api.CatalogueCounter, Labelled: true, Name: "errors", NameKnown: true,
+				Labels: []string{"error_type"}, LabelsKnown: true})
+			return true
+		}
+
+		unit, unitKnown := unitOf(info, expr)
+
+		if method == "Time" && len(expr.Args) > 0 {
+			findTime(info, expr, method, expr.Args[0], unit, unitKnown, fn)
+			return true
+		}
+
+		positions, ok := methods[method]
+		if !ok || len(expr.Args) == 0 {
+			return true
+		}
+
+		call := Call{Expr: expr, Method: method, Type: positions.metricType, Labelled: positions.labels >= 0, LabelsKnown: true}
+		call.Name, call.NameKnown = stringValue(info, expr.Args[0])
+		call.NameKnown = call.NameKnown && unitKnown
+		call.applyUnit(unit, positions.timed)
+
+		if positions.labels >= 0 && positions.labels < len(expr.Args) {
+			call.Labels, call.LabelsKnown = stringValues(info, expr.Args[positions.labels])
+		}
+		if positions.desc >= 0 && positions.desc < len(expr.Args) && !expr.Ellipsis.IsValid() {
+			call.Help, _ = stringValue(info, expr.Args[positions.desc])
+		}
+		if positions.buckets >= 0 && positions.buckets < len(expr.Args) {
+			call.Buckets = floatValues(info, expr.Args[positions.buckets])
+		}
+
+		fn(call)
+		return true
+	})
+}
+
+// findTime reports the timer, outcome counter and errors counter that Time and TimeResult create
+func findTime(info *types.Info, expr *ast.CallExpr, method string, nameArg ast.Expr, unit api.Unit, unitKnown bool, fn func(Call)) {
+	name, known := stringValue(info, nameArg)
+	timer := Call{Expr: expr, Method: method, Type: api.CatalogueTimer, Name: name, NameKnown: known && unitKnown, LabelsKnown: true}
+	timer.applyUnit(unit, true)
+	fn(timer)
+	fn(Call{Expr: expr, Method: method, Type: api.CatalogueCounter, Labelled: true, Name: name + "_calls_total", NameKnown: known,
+		Labels: []string{"outcome"}, LabelsKnown: true})
+	fn(Call{Expr: expr, Method: "Error", Type: api.CatalogueCounter, Labelled: true, Name: "errors", NameKnown: true,
+		Labels: []string{"error_type"}, LabelsKnown: true})
+}
+
+func findFunction(info *types.Info, expr *ast.CallExpr, function string, fn func(Call)) {
+	if function == "TimeResult" {
+		if len(expr.Args) > 1 {
+			unit, unitKnown := unitOfMetrics(info, expr.Args[0])
+			findTime(info, expr, function, expr.Args[1], unit, unitKnown, fn)
+		}
+		return
+	}
+
+	if function == "ErrorCtx" {
+		fn(Call{Expr: expr, Method: "Error", Type: api.CatalogueCounter, Labelled: true, Name: "errors", NameKnown: true,
+			Labels: []string{"error_type"}, LabelsKnown: true})
+		return
+	}
+
+	metricType, ok := contextFunctions[function]
+	if !ok || len(expr.Args) < 2 {
+		return
+	}
+
+	call := Call{Expr: expr, Method: function, Type: metricType, Labelled: len(expr.Args) > 2, LabelsKnown: true}
+	call.Name, call.NameKnown = stringValue(info, expr.Args[1])
+	if call.Labelled {
+		if expr.Ellipsis.IsValid() {
+			call.Labels, call.LabelsKnown = stringValues(info, expr.Args[2])
+		} else {
+			call.Labels, call.LabelsKnown = constantStrings(info, expr.Args[2:])
+		}
+	}
+	fn(call)
+}
+
+// unitOf returns the unit of metrics created through WithUnit, e.g. metrics.WithUnit(promenade.Bytes).Counter("sent"),
+// and whether it is known. Units given to metrics stored in a variable are not followed.
+func unitOf(info *types.Info, call *ast.CallExpr) (api.Unit, bool) {
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return api.Unit{}, true
+	}
+	return unitOfMetrics(info, selector.X)
+}
+
+// unitOfMetrics returns the unit given to WithUnit, if metrics is such a call, as unitOf does
+func unitOfMetrics(info *types.Info, metrics ast.Expr) (api.Unit, bool) {
+	receiver, ok := metrics.(*ast.CallExpr)
+	if !ok {
+		return api.Unit{}, true
+	}
+	if method, ok := APIMethod(info, receiver, "PrometheusMetrics", "PrometheusMetricsImpl"); !ok || method != "WithUnit" || len(receiver.Args) != 1 {
+		return api.Unit{}, true
+	}
+
+	switch arg := receiver.Args[0].(type) {
+	case *ast.SelectorExpr:
+		if unit, ok := units[arg.Sel.Name]; ok && isAPIObject(info, arg.Sel) {
+			return unit, true
+		}
+	case *ast.Ident:
+		if unit, ok := units[arg.Name]; ok && isAPIObject(info, arg) {
+			return unit, true
+		}
+	case *ast.CallExpr:
+		if function, ok := arg.Fun.(*ast.SelectorExpr); ok && function.Sel.Name == "CustomUnit" && isAPIObject(info, function.Sel) && len(arg.Args) == 1 {
+			if name, ok := stringValue(info, arg.Args[0]); ok {
+				return api.CustomUnit(name), true
+			}
+		}
+	case *ast.CompositeLit:
+		if len(arg.Elts) == 0 {
+			return api.Unit{}, true // Unit{}, i.e. none
+		}
+	}
+	return api.Unit{}, false
+}
+
+func isAPIObject(info *types.Info, ident *ast.Ident) bool {
+	object := info.Uses[ident]
+	return object != nil && object.Pkg() != nil && object.Pkg().Path() == apiPath
+}
+
+// applyUnit adds unit to the name, as the constructor would. Timed metrics ignore units other than time.
+func (c *Call) applyUnit(unit api.Unit, timed bool) {
+	if timed && unit != api.Seconds && unit != api.Milliseconds {
+		return
+	}
+	c.Name = unit.Suffix(c.Name)
+	c.Unit = unit.String()
+}
+
+// APIFunction returns the name of the promenade package function call invokes, if any, including generic ones
+// given explicit type arguments
+func APIFunction(info *types.Info, call *ast.CallExpr) (string, bool) {
+	fun := call.Fun
+	switch index := fun.(type) {
+	case *ast.IndexExpr:
+		fun = index.X
+	case *ast.IndexListExpr:
+		fun = index.X
+	}
+
+	var ident *ast.Ident
+	switch fun := fun.(type) {
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	case *ast.Ident:
+		ident = fun
+	default:
+		return "", false
+	}
+
+	function, ok := info.Uses[ident].(*types.Func)
+	if !ok || function.Pkg() == nil || function.Pkg().Path() != apiPath || function.Type().(*types.Signature).Recv() != nil {
+		return "", false
+	}
+	return function.Name(), true
+}
+
+// APIMethod returns the name of the method call invokes, if it is a method of one of the named promenade types
+func APIMethod(info *types.Info, call *ast.CallExpr, typeNames ...string) (string, bool) {
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+
+	selection, ok := info.Selections[selector]
+	if !ok || selection.Kind() != types.MethodVal {
+		return "", false
+	}
+
+	method := selection.Obj()
+	if method.Pkg() == nil || method.Pkg().Path() != apiPath {
+		return "", false
+	}
+
+	receiver := selection.Recv()
+	if pointer, ok := receiver.(*types.Pointer); ok {
+		receiver = pointer.Elem()
+	}
+	named, ok := receiver.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != apiPath {
+		return "", false
+	}
+
+	for _, each := range typeNames {
+		if named.Obj().Name() == each {
+			return method.Name(), true
+		}
+	}
+	return "", false
+}
+
+func stringValue(info *types.Info, expr ast.Expr) (string, bool) {
+	value := info.Types[expr].Value
+	if value == nil || value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(value), true
+}
+
+// stringValues accepts a single constant, or a []string literal of constants
+func stringValues(info *types.Info, expr ast.Expr) ([]string, bool) {
+	if single, ok := stringValue(info, expr); ok {
+		return []string{single}, true
+	}
+
+	literal, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil, false
+	}
+	return constantStrings(info, literal.Elts)
+}
+
+func constantStrings(info *types.Info, exprs []ast.Expr) ([]string, bool) {
+	values := make([]string, len(exprs))
+	for i, each := range exprs {
+		var ok bool
+		if values[i], ok = stringValue(info, each); !ok {
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+func floatValues(info *types.Info, expr ast.Expr) []float64 {
+	literal, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+
+	values := make([]float64, len(literal.Elts))
+	for i, each := range literal.Elts {
+		value := info.Types[each].Value
+		if value == nil {
+			return nil
+		}
+		values[i], _ = constant.Float64Val(constant.ToFloat(value))
+	}
+	return values
+}
@@ -0,0 +1,249 @@
+package otelbackend
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/poblish/promenade/api"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+const instrumentationName = "github.com/poblish/promenade"
+
+type otelBackend struct {
+	meter metric.Meter
+}
+
+// New returns a Backend that records through OpenTelemetry instruments created by the provider's Meter,
+// instead of registering Prometheus collectors. OpenTelemetry has no summary instrument, so summaries
+// (and hence timers) become histograms, with DefaultBuckets. Instruments the Meter can't create are reported
+// to OpenTelemetry's error handler, and discard everything.
+func New(provider metric.MeterProvider) api.Backend {
+	return otelBackend{meter: provider.Meter(instrumentationName)}
+}
+
+// ucumUnits maps promenade's units to the UCUM codes OpenTelemetry uses
+var ucumUnits = map[string]string{
+	"seconds":      "s",
+	"milliseconds": "ms",
+	"bytes":        "By",
+	"ratio":        "1",
+}
+
+// ucum returns the UCUM code for unit, or a UCUM annotation for custom units, e.g. {celsius}
+func ucum(unit string) string {
+	if unit == "" {
+		return ""
+	}
+	if code, ok := ucumUnits[unit]; ok {
+		return code
+	}
+	return "{" + unit + "}"
+}
+
+// timerUnit is seconds unless given another unit of time
+func timerUnit(unit string) string {
+	if unit == "" {
+		return "s"
+	}
+	return ucum(unit)
+}
+
+// defaultBuckets are DefaultBuckets in unit, as histograms of seconds or milliseconds, or the SDK's own for
+// other units
+func defaultBuckets(unit string) []float64 {
+	switch unit {
+	case "", "seconds":
+		return api.DefaultBuckets
+	case "milliseconds":
+		scaled := make([]float64, len(api.DefaultBuckets))
+		for i, each := range api.DefaultBuckets {
+			scaled[i] = each * 1000
+		}
+		return scaled
+	default:
+		return nil
+	}
+}
+
+func histogramOptions(help string, unit string, buckets []float64) []metric.Float64HistogramOption {
+	options := []metric.Float64HistogramOption{metric.WithDescription(help), metric.WithUnit(unit)}
+	if len(buckets) > 0 {
+		options = append(options, metric.WithExplicitBucketBoundaries(buckets...))
+	}
+	return options
+}
+
+// checked reports err to OpenTelemetry's error handler, as the SDK does its own, returning fallback rather than
+// an instrument that may be nil
+func checked[T any](instrument T, err error, fallback T) T {
+	if err != nil {
+		otel.Handle(fmt.Errorf("promenade: %w", err))
+		return fallback
+	}
+	return instrument
+}
+
+func (b otelBackend) NewCounter(opts api.InstrumentOpts) api.CounterInstrument {
+	internal, err := b.meter.Float64Counter(opts.Name, metric.WithDescription(opts.Help), metric.WithUnit(ucum(opts.Unit)))
+	internal = checked[metric.Float64Counter](internal, err, noop.Float64Counter{})
+	return otelCounter{counter: internal}
+}
+
+func (b otelBackend) NewCounterVec(opts api.InstrumentOpts) api.CounterVecInstrument {
+	internal, err := b.meter.Float64Counter(opts.Name, metric.WithDescription(opts.Help), metric.WithUnit(ucum(opts.Unit)))
+	internal = checked[metric.Float64Counter](internal, err, noop.Float64Counter{})
+	return otelCounterVec{counter: internal, labelNames: opts.LabelNames}
+}
+
+func (b otelBackend) NewGauge(opts api.InstrumentOpts) api.GaugeInstrument {
+	internal, err := b.meter.Float64Gauge(opts.Name, metric.WithDescription(opts.Help), metric.WithUnit(ucum(opts.Unit)))
+	internal = checked[metric.Float64Gauge](internal, err, noop.Float64Gauge{})
+	return &otelGauge{gauge: internal}
+}
+
+func (b otelBackend) NewGaugeVec(opts api.InstrumentOpts) api.GaugeVecInstrument {
+	internal, err := b.meter.Float64Gauge(opts.Name, metric.WithDescription(opts.Help), metric.WithUnit(ucum(opts.Unit)))
+	internal = checked[metric.Float64Gauge](internal, err, noop.Float64Gauge{})
+	return &otelGaugeVec{gauge: internal, labelNames: opts.LabelNames, children: make(map[attribute.Distinct]*otelGauge)}
+}
+
+func (b otelBackend) NewHistogram(opts api.InstrumentOpts) api.ObserverInstrument {
+	internal, err := b.meter.Float64Histogram(opts.Name, histogramOptions(opts.Help, ucum(opts.Unit), opts.Buckets)...)
+	internal = checked[metric.Float64Histogram](internal, err, noop.Float64Histogram{})
+	return otelHistogram{histogram: internal}
+}
+
+func (b otelBackend) NewSummary(opts api.InstrumentOpts) api.ObserverInstrument {
+	internal, err := b.meter.Float64Histogram(opts.Name, histogramOptions(opts.Help, ucum(opts.Unit), defaultBuckets(opts.Unit))...)
+	internal = checked[metric.Float64Histogram](internal, err, noop.Float64Histogram{})
+	return otelHistogram{histogram: internal}
+}
+
+func (b otelBackend) NewSummaryVec(opts api.InstrumentOpts) api.ObserverVecInstrument {
+	internal, err := b.meter.Float64Histogram(opts.Name, histogramOptions(opts.Help, ucum(opts.Unit), defaultBuckets(opts.Unit))...)
+	internal = checked[metric.Float64Histogram](internal, err, noop.Float64Histogram{})
+	return otelHistogramVec{histogram: internal, labelNames: opts.LabelNames}
+}
+
+func (b otelBackend) NewTimer(opts api.InstrumentOpts) api.ObserverInstrument {
+	internal, err := b.meter.Float64Histogram(opts.Name, histogramOptions(opts.Help, timerUnit(opts.Unit), defaultBuckets(opts.Unit))...)
+	internal = checked[metric.Float64Histogram](internal, err, noop.Float64Histogram{})
+	return otelHistogram{histogram: internal}
+}
+
+func (b otelBackend) NewTimerVec(opts api.InstrumentOpts) api.ObserverVecInstrument {
+	internal, err := b.meter.Float64Histogram(opts.Name, histogramOptions(opts.Help, timerUnit(opts.Unit), defaultBuckets(opts.Unit))...)
+	internal = checked[metric.Float64Histogram](internal, err, noop.Float64Histogram{})
+	return otelHistogramVec{histogram: internal, labelNames: opts.LabelNames}
+}
+
+type otelCounter struct {
+	counter metric.Float64Counter
+	options []metric.AddOption
+}
+
+func (c otelCounter) Inc() {
+	c.Add(1)
+}
+
+func (c otelCounter) Add(inc float64) {
+	c.counter.Add(context.Background(), inc, c.options...)
+}
+
+type otelCounterVec struct {
+	counter    metric.Float64Counter
+	labelNames []string
+}
+
+func (v otelCounterVec) WithLabelValues(labelValues ...string) api.CounterInstrument {
+	return otelCounter{counter: v.counter, options: []metric.AddOption{metric.WithAttributeSet(attributes(v.labelNames, labelValues))}}
+}
+
+// otelGauge keeps the current value itself, as OpenTelemetry gauges can only be set, not adjusted
+type otelGauge struct {
+	sync.Mutex
+	gauge   metric.Float64Gauge
+	options []metric.RecordOption
+	value   float64
+}
+
+func (g *otelGauge) Set(value float64) {
+	g.Lock()
+	defer g.Unlock()
+	g.value = value
+	g.gauge.Record(context.Background(), g.value, g.options...)
+}
+
+func (g *otelGauge) Add(inc float64) {
+	g.Lock()
+	defer g.Unlock()
+	g.value += inc
+	g.gauge.Record(context.Background(), g.value, g.options...)
+}
+
+func (g *otelGauge) Sub(dec float64) {
+	g.Add(-dec)
+}
+
+func (g *otelGauge) Inc() {
+	g.Add(1)
+}
+
+func (g *otelGauge) Dec() {
+	g.Add(-1)
+}
+
+type otelGaugeVec struct {
+	sync.Mutex
+	gauge      metric.Float64Gauge
+	labelNames []string
+	children   map[attribute.Distinct]*otelGauge
+}
+
+func (v *otelGaugeVec) WithLabelValues(labelValues ...string) api.GaugeInstrument {
+	attrs := attributes(v.labelNames, labelValues)
+
+	v.Lock()
+	defer v.Unlock()
+	child, ok := v.children[attrs.Equivalent()]
+	if !ok {
+		child = &otelGauge{gauge: v.gauge, options: []metric.RecordOption{metric.WithAttributeSet(attrs)}}
+		v.children[attrs.Equivalent()] = child
+	}
+	return child
+}
+
+type otelHistogram struct {
+	histogram metric.Float64Histogram
+	options   []metric.RecordOption
+}
+
+func (h otelHistogram) Observe(value float64) {
+	h.histogram.Record(context.Background(), value, h.options...)
+}
+
+type otelHistogramVec struct {
+	histogram  metric.Float64Histogram
+	labelNames []string
+}
+
+func (v otelHistogramVec) WithLabelValues(labelValues ...string) api.ObserverInstrument {
+	return otelHistogram{histogram: v.histogram, options: []metric.RecordOption{metric.WithAttributeSet(attributes(v.labelNames, labelValues))}}
+}
+
+func attributes(labelNames []string, labelValues []string) attribute.Set {
+	if len(labelNames) != len(labelValues) {
+		panic(fmt.Sprintf("expected %d label values but got %d in %v", len(labelNames), len(labelValues), labelValues))
+	}
+
+	attrs := make([]attribute.KeyValue, len(labelNames))
+	for i, name := range labelNames {
+		attrs[i] = attribute.String(name, labelValues[i])
+	}
+	return attribute.NewSet(attrs...)
+}
@@ -0,0 +1,197 @@
+package otelbackend
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/poblish/promenade/api"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestOtelCounters(t *testing.T) {
+	metrics, reader := newOtelTestMetrics("z")
+
+	c := metrics.Counter("Mine", "desc")
+	c.Inc()
+	metrics.Counter("Mine").Inc()
+	c.IncBy(7)
+
+	l := metrics.CounterWithLabels("animals", []string{"animal", "breed"})
+	l.IncLabel("cat", "persian")
+	l.IncLabelBy("cat", "persian").Value(3)
+	l.IncLabel("dog", "mutt")
+
+	collected := collectOK(t, reader)
+
+	m := findOtelMetric("z_mine", collected)
+	assert.Equal(t, "desc", m.Description)
+	assert.Equal(t, 9.0, m.Data.(metricdata.Sum[float64]).DataPoints[0].Value)
+
+	points := m.Data.(metricdata.Sum[float64]).DataPoints
+	assert.Len(t, points, 1)
+
+	labelled := findOtelMetric("z_animals", collected).Data.(metricdata.Sum[float64])
+	assert.Equal(t, 4.0, otelSumValue(labelled, attribute.String("animal", "cat"), attribute.String("breed", "persian")))
+	assert.Equal(t, 1.0, otelSumValue(labelled, attribute.String("animal", "dog"), attribute.String("breed", "mutt")))
+
+	assert.Panics(t, func() { l.IncLabel("cat") })
+}
+
+func TestOtelGauges(t *testing.T) {
+	metrics, reader := newOtelTestMetrics("x-service#123")
+
+	g := metrics.Gauge("MyGauge")
+	g.SetValue(100)
+	g.Inc()
+	g.IncBy(5)
+	g.Dec()
+	g.DecBy(4)
+	metrics.Gauge("MyGauge").Inc()
+
+	l := metrics.GaugeWithLabel("current animals", "animal")
+	l.SetLabels("fleas").Value(1000)
+	l.IncLabels("dog")
+	l.DecLabelsBy("fleas").Value(15)
+
+	collected := collectOK(t, reader)
+	assert.Equal(t, 102.0, findOtelMetric("x_service_123_mygauge", collected).Data.(metricdata.Gauge[float64]).DataPoints[0].Value)
+
+	labelled := findOtelMetric("x_service_123_current_animals", collected).Data.(metricdata.Gauge[float64])
+	assert.Len(t, labelled.DataPoints, 2)
+	for _, each := range labelled.DataPoints {
+		if animal, _ := each.Attributes.Value("animal"); animal.AsString() == "fleas" {
+			assert.Equal(t, 985.0, each.Value)
+		} else {
+			assert.Equal(t, 1.0, each.Value)
+		}
+	}
+}
+
+func TestOtelHistogramsAndTimers(t *testing.T) {
+	metrics, reader := newOtelTestMetrics("A")
+
+	h := metrics.Histogram("MyHisto", []float64{2.0, 3.0, 3.5})
+	h.Update(1.3)
+	h.Update(2.5)
+	h.Update(3.834344)
+
+	metrics.SummaryWithLabel("animal facts", "animal").Observe(1.0, "cat")
+	metrics.Timer("Timer").Stop()
+	metrics.Timer("Timer").Stop()
+
+	collected := collectOK(t, reader)
+
+	histo := findOtelMetric("a_myhisto", collected).Data.(metricdata.Histogram[float64]).DataPoints[0]
+	assert.Equal(t, uint64(3), histo.Count)
+	assert.Equal(t, []float64{2.0, 3.0, 3.5}, histo.Bounds)
+	assert.Equal(t, []uint64{1, 1, 0, 1}, histo.BucketCounts)
+
+	timer := findOtelMetric("a_timer", collected).Data.(metricdata.Histogram[float64]).DataPoints[0]
+	assert.Equal(t, uint64(2), timer.Count)
+	assert.Equal(t, "s", findOtelMetric("a_timer", collected).Unit)
+
+	summary := findOtelMetric("a_animal_facts", collected).Data.(metricdata.Histogram[float64]).DataPoints[0]
+	assert.Equal(t, uint64(1), summary.Count)
+}
+
+func TestOtelUnits(t *testing.T) {
+	metrics, reader := newOtelTestMetrics("A")
+
+	metrics.WithUnit(api.Bytes).Histogram("payload", []float64{100, 1000}).Update(512)
+	metrics.WithUnit(api.Milliseconds).Timer("query").Stop()
+	metrics.WithUnit(api.CustomUnit("celsius")).Gauge("temperature").SetValue(21)
+
+	collected := collectOK(t, reader)
+	assert.Equal(t, "By", findOtelMetric("a_payload_bytes", collected).Unit)
+	assert.Equal(t, "ms", findOtelMetric("a_query_milliseconds", collected).Unit)
+	assert.Equal(t, "{celsius}", findOtelMetric("a_temperature_celsius", collected).Unit)
+}
+
+func TestOtelTimerBuckets(t *testing.T) {
+	metrics, reader := newOtelTestMetrics("A")
+
+	stopwatch := metrics.Timer("calc")
+	stopwatch.ObserveDuration(300 * time.Millisecond)
+	metrics.WithUnit(api.Milliseconds).Timer("query").ObserveDuration(300 * time.Millisecond)
+	metrics.Summary("sizes").Observe(0.3)
+
+	collected := collectOK(t, reader)
+	for _, name := range []string{"a_calc", "a_sizes"} {
+		point := findOtelMetric(name, collected).Data.(metricdata.Histogram[float64]).DataPoints[0]
+		assert.Equal(t, api.DefaultBuckets, point.Bounds, name)
+		assert.Equal(t, uint64(1), point.BucketCounts[6], name) // 0.25 < 0.3 <= 0.5
+	}
+
+	query := findOtelMetric("a_query_milliseconds", collected).Data.(metricdata.Histogram[float64]).DataPoints[0]
+	assert.Equal(t, 250.0, query.Bounds[5])
+	assert.Equal(t, uint64(1), query.BucketCounts[6])
+}
+
+func TestOtelInstrumentErrors(t *testing.T) {
+	var reported []error
+	previous := otel.GetErrorHandler()
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) { reported = append(reported, err) }))
+	defer otel.SetErrorHandler(previous)
+
+	reader := sdkmetric.NewManualReader()
+	metrics := api.NewMetrics(api.MetricOpts{Backend: New(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))), NameValidation: api.UTF8Names})
+
+	assert.NotPanics(t, func() {
+		metrics.Counter("größe_total").Inc()
+		metrics.Timer("dauer_größe").Stop()
+	})
+	assert.Len(t, reported, 2)
+	assert.ErrorContains(t, reported[0], "größe_total")
+
+	var collected metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &collected))
+	assert.Empty(t, collected.ScopeMetrics) // both discarded
+}
+
+func TestOtelErrors(t *testing.T) {
+	metrics, reader := newOtelTestMetrics("z")
+	metrics.Error("bad")
+	metrics.Error("generic")
+	metrics.Error("generic")
+
+	errors := findOtelMetric("z_errors", collectOK(t, reader)).Data.(metricdata.Sum[float64])
+	assert.Equal(t, 1.0, otelSumValue(errors, attribute.String("error_type", "bad")))
+	assert.Equal(t, 2.0, otelSumValue(errors, attribute.String("error_type", "generic")))
+}
+
+func newOtelTestMetrics(prefix string) (api.PrometheusMetricsImpl, *sdkmetric.ManualReader) {
+	reader := sdkmetric.NewManualReader()
+	backend := New(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	return api.NewMetrics(api.MetricOpts{MetricNamePrefix: prefix, Backend: backend}), reader
+}
+
+func collectOK(t *testing.T, reader *sdkmetric.ManualReader) []metricdata.Metrics {
+	var collected metricdata.ResourceMetrics
+	assert.Nil(t, reader.Collect(context.Background(), &collected))
+	assert.Len(t, collected.ScopeMetrics, 1)
+	return collected.ScopeMetrics[0].Metrics
+}
+
+func findOtelMetric(metricName string, collected []metricdata.Metrics) metricdata.Metrics {
+	for _, each := range collected {
+		if each.Name == metricName {
+			return each
+		}
+	}
+	return metricdata.Metrics{}
+}
+
+func otelSumValue(sum metricdata.Sum[float64], attrs ...attribute.KeyValue) float64 {
+	wanted := attribute.NewSet(attrs...)
+	for _, each := range sum.DataPoints {
+		if each.Attributes.Equals(&wanted) {
+			return each.Value
+		}
+	}
+	return -1
+}
@@ -0,0 +1,18 @@
+package otelbackend
+
+import (
+	"context"
+
+	"github.com/poblish/promenade/api"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithSpanExemplar returns a context whose exemplar, for the api Ctx helpers, is the span in ctx, or ctx itself
+// if it has no valid span. Call it after starting each span, e.g. in tracing middleware.
+func WithSpanExemplar(ctx context.Context) context.Context {
+	span := trace.SpanContextFromContext(ctx)
+	if !span.IsValid() {
+		return ctx
+	}
+	return api.WithExemplar(ctx, api.Exemplar{TraceID: span.TraceID().String(), SpanID: span.SpanID().String()})
+}
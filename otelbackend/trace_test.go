@@ -0,0 +1,21 @@
+package otelbackend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/poblish/promenade/api"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWithSpanExemplar(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, ctx, WithSpanExemplar(ctx))
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+
+	assert.Equal(t, api.Exemplar{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"}, api.ExemplarFromContext(WithSpanExemplar(ctx)))
+}
@@ -0,0 +1,211 @@
+// Package promenadecheck provides an Analyzer reporting misuse of the promenade API that would otherwise only
+// show up at runtime, if at all. Run it with go vet -vettool, via cmd/promenade-vet, or in a multichecker.
+package promenadecheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/poblish/promenade/api"
+	"github.com/poblish/promenade/internal/apicalls"
+	"golang.org/x/tools/go/analysis"
+)
+
+var Analyzer = &analysis.Analyzer{
+	Name: "promenade",
+	Doc: `check for misuse of promenade metrics
+
+Reports timers that are started but never stopped, e.g. defer metrics.Timer("x") without the
+trailing .Stop(), labelled metrics given the wrong number of label values, and metric names used for
+different types of metric within a package, which panics at runtime. Test files often create
+separate metrics for each test, so names in them are not checked.`,
+	Run: run,
+}
+
+// labelValueMethods gives the index of the first label value argument of each facade method taking them
+var labelValueMethods = map[string]int{
+	"IncLabel":             0,
+	"IncLabelBy":           0,
+	"IncLabelWithExemplar": 1,
+	"IncLabels":            0,
+	"DecLabels":            0,
+	"IncLabelsBy":          0,
+	"DecLabelsBy":          0,
+	"SetLabels":            0,
+	"Observe":              1,
+}
+
+type firstUse struct {
+	kind     string
+	position token.Pos
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	created := make(map[*ast.CallExpr]apicalls.Call)
+	firstUses := make(map[string]firstUse)
+
+	for _, file := range pass.Files {
+		isTest := strings.HasSuffix(pass.Fset.Position(file.Pos()).Filename, "_test.go")
+		apicalls.Find(pass.TypesInfo, file, func(call apicalls.Call) {
+			created[call.Expr] = call
+			if !isTest {
+				checkType(pass, call, firstUses)
+			}
+		})
+	}
+
+	assigned := labelledVariables(pass, created)
+
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.DeferStmt:
+				checkTimerStopped(pass, node.Call, "defer ")
+			case *ast.ExprStmt:
+				if call, ok := node.X.(*ast.CallExpr); ok {
+					checkTimerStopped(pass, call, "")
+				}
+			case *ast.CallExpr:
+				checkLabelValues(pass, node, created, assigned)
+			}
+			return true
+		})
+	}
+	return nil, nil
+}
+
+// checkType reports a name already used for a different type of metric, as getOrAdd would panic
+func checkType(pass *analysis.Pass, call apicalls.Call, firstUses map[string]firstUse) {
+	if !call.NameKnown || call.Method == "Error" {
+		return
+	}
+
+	kind := call.Type
+	if kind == api.CatalogueTimer {
+		kind = api.CatalogueSummary // timers are summaries, so can share a name with one
+	}
+	if call.Labelled {
+		kind = "labelled " + kind
+	}
+
+	key := api.NormaliseAndLowercaseName(call.Name)
+	first, ok := firstUses[key]
+	if !ok {
+		firstUses[key] = firstUse{kind: kind, position: call.Expr.Pos()}
+		return
+	}
+
+	if first.kind != kind {
+		pass.Reportf(call.Expr.Pos(), "metric %q is used as a %s here, but as a %s at %s", call.Name, kind, first.kind, pass.Fset.Position(first.position))
+	}
+}
+
+// checkTimerStopped reports a Timer call whose stopwatch is discarded
+func checkTimerStopped(pass *analysis.Pass, call *ast.CallExpr, prefix string) {
+	method, ok := apicalls.APIMethod(pass.TypesInfo, call, "PrometheusMetrics", "PrometheusMetricsImpl")
+	if !ok {
+		method, ok = apicalls.APIFunction(pass.TypesInfo, call)
+	}
+	if !ok || (method != "Timer" && method != "TimerWithLabel" && method != "TimerWithLabels" && method != "TimerCtx") {
+		return
+	}
+	pass.Reportf(call.Pos(), "the timer started by %s is never stopped; did you mean %s%s.Stop()?", method, prefix, render(pass.Fset, call))
+}
+
+// checkLabelValues reports label values that don't match the label names the metric was created with
+func checkLabelValues(pass *analysis.Pass, call *ast.CallExpr, created map[*ast.CallExpr]apicalls.Call, assigned map[types.Object][]string) {
+	method, ok := apicalls.APIMethod(pass.TypesInfo, call, "LabelledCounterFacade", "LabelledGaugeFacade", "LabelledSummaryFacade")
+	if !ok || call.Ellipsis.IsValid() {
+		return
+	}
+	first, ok := labelValueMethods[method]
+	if !ok {
+		return
+	}
+
+	var labels []string
+	switch receiver := ast.Unparen(call.Fun.(*ast.SelectorExpr).X).(type) {
+	case *ast.CallExpr:
+		creation, ok := created[receiver]
+		if !ok || !creation.LabelsKnown {
+			return
+		}
+		labels = creation.Labels
+	case *ast.Ident:
+		if labels, ok = assigned[pass.TypesInfo.ObjectOf(receiver)]; !ok {
+			return
+		}
+	default:
+		return
+	}
+
+	if given := len(call.Args) - first; given != len(labels) {
+		pass.Reportf(call.Pos(), "%s given %d label values, but the metric has %d labels %v", method, given, len(labels), labels)
+	}
+}
+
+// labelledVariables finds variables that are only ever assigned labelled metrics with the same known label
+// names. Variables assigned anything else are left out, as are struct fields.
+func labelledVariables(pass *analysis.Pass, created map[*ast.CallExpr]apicalls.Call) map[types.Object][]string {
+	assigned := make(map[types.Object][]string)
+	unknown := make(map[types.Object]bool)
+
+	assign := func(ident *ast.Ident, value ast.Expr) {
+		object := pass.TypesInfo.ObjectOf(ident)
+		if object == nil || unknown[object] {
+			return
+		}
+
+		call, ok := value.(*ast.CallExpr)
+		creation, created := created[call]
+		if !ok || !created || !creation.LabelsKnown || !creation.Labelled {
+			unknown[object] = true
+			delete(assigned, object)
+			return
+		}
+
+		if previous, ok := assigned[object]; ok && strings.Join(previous, ",") != strings.Join(creation.Labels, ",") {
+			unknown[object] = true
+			delete(assigned, object)
+			return
+		}
+		assigned[object] = creation.Labels
+	}
+
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.AssignStmt:
+				if len(node.Lhs) != len(node.Rhs) {
+					return true
+				}
+				for i, lhs := range node.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok {
+						assign(ident, ast.Unparen(node.Rhs[i]))
+					}
+				}
+			case *ast.ValueSpec:
+				if len(node.Names) != len(node.Values) {
+					return true
+				}
+				for i, ident := range node.Names {
+					assign(ident, ast.Unparen(node.Values[i]))
+				}
+			}
+			return true
+		})
+	}
+	return assigned
+}
+
+func render(fset *token.FileSet, node ast.Node) string {
+	var buf strings.Builder
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return fmt.Sprintf("%T", node)
+	}
+	return buf.String()
+}
@@ -0,0 +1,27 @@
+package a
+
+import "github.com/poblish/promenade/api"
+
+var shared = api.LabelledCounterFacade{}
+
+func labels(metrics api.PrometheusMetrics, values []string) {
+	metrics.CounterWithLabel("requests", "method").IncLabel("GET")      // ok
+	metrics.CounterWithLabel("requests", "method").IncLabel("GET", "/") // want `IncLabel given 2 label values, but the metric has 1 labels \[method\]`
+	metrics.CounterWithLabel("requests", "method").IncLabel(values...)  // ok, can't tell
+	metrics.SummaryWithLabel("sizes", "city").Observe(1)                // want `Observe given 0 label values, but the metric has 1 labels \[city\]`
+	metrics.SummaryWithLabel("sizes", "city").Observe(1, "London")      // ok
+
+	queue := metrics.GaugeWithLabels("queue", []string{"priority", "region"})
+	queue.SetLabels("high") // want `SetLabels given 1 label values, but the metric has 2 labels \[priority region\]`
+	queue.SetLabels("high", "eu")
+
+	var animals = metrics.CounterWithLabels("animals", []string{"type", "breed"})
+	animals.IncLabel("cat") // want `IncLabel given 1 label values, but the metric has 2 labels \[type breed\]`
+
+	reassigned := metrics.CounterWithLabel("requests", "method")
+	reassigned = shared
+	reassigned.IncLabel("a", "b", "c") // ok, can't tell
+
+	dynamic := metrics.CounterWithLabels("dynamic", values)
+	dynamic.IncLabel("a", "b") // ok, can't tell
+}
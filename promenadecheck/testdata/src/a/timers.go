@@ -0,0 +1,22 @@
+package a
+
+import (
+	"context"
+
+	"github.com/poblish/promenade/api"
+)
+
+func timed(metrics api.PrometheusMetrics, impl *api.PrometheusMetricsImpl) {
+	defer metrics.Timer("calc").Stop()              // ok
+	defer metrics.Timer("calc")                     // want `the timer started by Timer is never stopped; did you mean defer metrics.Timer\("calc"\).Stop\(\)\?`
+	defer impl.Timer("calc")                        // want `the timer started by Timer is never stopped`
+	metrics.TimerWithLabel("fetch", "source", "db") // want `the timer started by TimerWithLabel is never stopped; did you mean metrics.TimerWithLabel\("fetch", "source", "db"\).Stop\(\)\?`
+
+	stopwatch := metrics.Timer("calc") // ok
+	stopwatch.Stop()
+}
+
+func timedCtx(ctx context.Context) {
+	defer api.TimerCtx(ctx, "render", "tenant").Stop() // ok
+	api.TimerCtx(ctx, "calc")                          // want `the timer started by TimerCtx is never stopped; did you mean api.TimerCtx\(ctx, "calc"\).Stop\(\)\?`
+}
@@ -0,0 +1,15 @@
+package a
+
+import "github.com/poblish/promenade/api"
+
+func types(metrics api.PrometheusMetrics) {
+	metrics.Gauge("Requests") // want `metric "Requests" is used as a gauge here, but as a labelled counter at .*labels.go:8:2`
+	metrics.Counter("sizes")  // want `metric "sizes" is used as a counter here, but as a labelled summary at .*labels.go:11:2`
+	metrics.Summary("calc")   // ok, as timers are summaries
+	metrics.Counter("errors") // ok, as the shared errors counter isn't registered by name
+	metrics.Error("oops")
+	metrics.CounterWithLabel("requests", "path") // ok, as label names aren't checked
+
+	api.TimeResult(metrics, "load", func() (int, error) { return 0, nil })
+	metrics.Gauge("load_calls_total") // want `metric "load_calls_total" is used as a gauge here, but as a labelled counter at .*types.go:13:2`
+}
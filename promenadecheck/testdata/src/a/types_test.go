@@ -0,0 +1,13 @@
+package a
+
+import (
+	"testing"
+
+	"github.com/poblish/promenade/api"
+)
+
+func TestTypes(t *testing.T) {
+	var metrics api.PrometheusMetrics
+	metrics.Gauge("requests") // ok, in a test
+	metrics.Counter("calc")
+}
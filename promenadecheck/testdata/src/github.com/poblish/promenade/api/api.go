@@ -0,0 +1,71 @@
+// Package api is a stub of the promenade API, as seen by the analyzer
+package api
+
+import (
+	"context"
+	"time"
+)
+
+type PrometheusMetrics interface {
+	Counter(name string, optionalDesc ...string) CounterFacade
+	CounterWithLabel(name string, labelName string, optionalDesc ...string) LabelledCounterFacade
+	CounterWithLabels(name string, labelNames []string, optionalDesc ...string) LabelledCounterFacade
+	Error(name string) ErrorCounter
+	Gauge(name string, optionalDesc ...string) GaugeFacade
+	GaugeWithLabels(name string, labelNames []string, optionalDesc ...string) LabelledGaugeFacade
+	Summary(name string, optionalDesc ...string) SummaryFacade
+	SummaryWithLabel(name string, labelName string, optionalDesc ...string) LabelledSummaryFacade
+	Timer(Name string) *Stopwatch
+	TimerWithLabel(Name string, labelName string, labelValue string) *Stopwatch
+}
+
+type PrometheusMetricsImpl struct{}
+
+func (p *PrometheusMetricsImpl) Counter(name string, optionalDesc ...string) CounterFacade {
+	return CounterFacade{}
+}
+
+func (p *PrometheusMetricsImpl) Timer(Name string) *Stopwatch {
+	return nil
+}
+
+func TimerCtx(ctx context.Context, name string, labelNames ...string) *Stopwatch {
+	return nil
+}
+
+func TimeResult[T any](metrics PrometheusMetrics, name string, fn func() (T, error)) (T, error) {
+	return fn()
+}
+
+type Stopwatch struct{}
+
+func (s *Stopwatch) Stop(exemplars ...Exemplar) time.Duration {
+	return 0
+}
+
+type CounterFacade struct{}
+
+func (f CounterFacade) Inc() {}
+
+type ErrorCounter struct{}
+
+type GaugeFacade struct{}
+
+type SummaryFacade struct{}
+
+type LabelledCounterFacade struct{}
+
+func (f LabelledCounterFacade) IncLabel(labelValues ...string) {}
+
+type LabelledGaugeFacade struct{}
+
+func (f LabelledGaugeFacade) SetLabels(labelValues ...string) {}
+
+type LabelledSummaryFacade struct{}
+
+func (f LabelledSummaryFacade) Observe(value float64, labelValues ...string) {}
+
+type Exemplar struct {
+	TraceID string
+	SpanID  string
+}
@@ -0,0 +1,59 @@
+package promtest
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/poblish/promenade/api"
+)
+
+// updateEnv, when 1, rewrites golden files instead of comparing against them. An environment variable rather
+// than a flag, so it can't clash with the flags of packages under test, and works for go test ./...
+const updateEnv = "PROMTEST_UPDATE"
+
+func updating() bool {
+	return os.Getenv(updateEnv) == "1"
+}
+
+// AssertGolden compares a value-masked TestHelper snapshot against the golden file at path, so that renaming
+// a metric, or changing its type, help or label names, fails the test until the file is updated. Label values
+// are masked too, so tests seeing different values still match. Run the tests
+// with PROMTEST_UPDATE=1 to write the current snapshot instead.
+func AssertGolden(t TestingT, metrics api.PrometheusMetrics, path string) bool {
+	t.Helper()
+	actual, err := metrics.TestHelper().Snapshot(true)
+	if err != nil {
+		t.Errorf("could not snapshot metrics: %s", err)
+		return false
+	}
+
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Errorf("could not create %s: %s", filepath.Dir(path), err)
+			return false
+		}
+		if err := os.WriteFile(path, []byte(actual), 0o644); err != nil {
+			t.Errorf("could not write golden file: %s", err)
+			return false
+		}
+		return true
+	}
+
+	expected, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("golden file %s not found, run with PROMTEST_UPDATE=1 to create it", path)
+		return false
+	}
+	if err != nil {
+		t.Errorf("could not read golden file: %s", err)
+		return false
+	}
+
+	if string(expected) != actual {
+		t.Errorf("metrics differ from golden file %s, run with PROMTEST_UPDATE=1 if this is intended\n\n%s", path, diff(string(expected), actual))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,46 @@
+package promtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGolden(t *testing.T) {
+	metrics := newTestMetrics()
+	metrics.CounterWithLabel("requests", "method").IncLabel("GET")
+	metrics.Histogram("latency", []float64{0.1, 1}, "Time taken").Update(0.2)
+
+	AssertGolden(t, metrics, "testdata/golden.txt")
+
+	// values are masked, and label values too
+	metrics.CounterWithLabel("requests", "method").IncLabel("GET")
+	metrics.CounterWithLabel("requests", "method").IncLabel("POST")
+	AssertGolden(t, metrics, "testdata/golden.txt")
+
+	metrics.Gauge("queue").SetValue(1)
+	fake := &fakeT{}
+	assert.False(t, AssertGolden(fake, metrics, "testdata/golden.txt"))
+	assert.Equal(t, 1, len(fake.errors))
+	assert.Contains(t, fake.errors[0], "metrics differ from golden file testdata/golden.txt")
+	assert.Contains(t, fake.errors[0], "+# HELP svc_queue svc_queue\n+# TYPE svc_queue gauge\n+svc_queue *\n")
+}
+
+func TestGoldenUpdate(t *testing.T) {
+	metrics := newTestMetrics()
+	metrics.Gauge("queue").SetValue(1)
+	path := filepath.Join(t.TempDir(), "new", "golden.txt")
+
+	fake := &fakeT{}
+	assert.False(t, AssertGolden(fake, metrics, path))
+	assert.Equal(t, []string{"golden file " + path + " not found, run with PROMTEST_UPDATE=1 to create it"}, fake.errors)
+
+	t.Setenv(updateEnv, "1")
+	AssertGolden(t, metrics, path)
+
+	written, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "# HELP svc_queue svc_queue\n# TYPE svc_queue gauge\nsvc_queue *\n", string(written))
+}
@@ -0,0 +1,251 @@
+// Package promtest provides testify-style assertions on promenade metrics. Metric names are given as they were
+// when the metrics were created, i.e. before prefixing and normalisation, and label values in label name order.
+// Failures report expected vs. actual exposition text.
+package promtest
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/poblish/promenade/api"
+	clientmodel "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// TestingT is the subset of *testing.T the assertions need
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+	Helper()
+}
+
+func AssertCounter(t TestingT, metrics api.PrometheusMetrics, name string, expected float64, labelValues ...string) bool {
+	t.Helper()
+	family, series, ok := findSeries(t, metrics, name, clientmodel.MetricType_COUNTER, labelValues)
+	if !ok {
+		return false
+	}
+
+	if actual := series.GetCounter().GetValue(); actual != expected {
+		failWithDiff(t, family, fmt.Sprintf("counter %s: expected %v but was %v", family.GetName(), expected, actual), func() {
+			series.Counter.Value = &expected
+		})
+		return false
+	}
+	return true
+}
+
+// AssertCounterDelta checks that running fn increases the counter by delta. The series need not exist beforehand.
+func AssertCounterDelta(t TestingT, metrics api.PrometheusMetrics, name string, delta float64, fn func(), labelValues ...string) bool {
+	t.Helper()
+	before, err := metrics.TestHelper().CounterValue(name, labelValues...)
+	if err != nil {
+		before = 0
+	}
+
+	fn()
+
+	family, series, ok := findSeries(t, metrics, name, clientmodel.MetricType_COUNTER, labelValues)
+	if !ok {
+		return false
+	}
+
+	if actual := series.GetCounter().GetValue() - before; actual != delta {
+		expected := before + delta
+		failWithDiff(t, family, fmt.Sprintf("counter %s: expected to change by %v but changed by %v", family.GetName(), delta, actual), func() {
+			series.Counter.Value = &expected
+		})
+		return false
+	}
+	return true
+}
+
+func AssertGauge(t TestingT, metrics api.PrometheusMetrics, name string, expected float64, labelValues ...string) bool {
+	t.Helper()
+	family, series, ok := findSeries(t, metrics, name, clientmodel.MetricType_GAUGE, labelValues)
+	if !ok {
+		return false
+	}
+
+	if actual := series.GetGauge().GetValue(); actual != expected {
+		failWithDiff(t, family, fmt.Sprintf("gauge %s: expected %v but was %v", family.GetName(), expected, actual), func() {
+			series.Gauge.Value = &expected
+		})
+		return false
+	}
+	return true
+}
+
+// AssertGaugeBetween checks that the gauge is within min and max inclusive
+func AssertGaugeBetween(t TestingT, metrics api.PrometheusMetrics, name string, min float64, max float64, labelValues ...string) bool {
+	t.Helper()
+	family, series, ok := findSeries(t, metrics, name, clientmodel.MetricType_GAUGE, labelValues)
+	if !ok {
+		return false
+	}
+
+	if actual := series.GetGauge().GetValue(); actual < min || actual > max {
+		t.Errorf("gauge %s: expected between %v and %v but was %v\n\n%s", family.GetName(), min, max, actual, exposition(family))
+		return false
+	}
+	return true
+}
+
+// AssertHistogramObservations checks the number of values the histogram has observed
+func AssertHistogramObservations(t TestingT, metrics api.PrometheusMetrics, name string, expected uint64, labelValues ...string) bool {
+	t.Helper()
+	family, series, ok := findSeries(t, metrics, name, clientmodel.MetricType_HISTOGRAM, labelValues)
+	if !ok {
+		return false
+	}
+
+	if actual := series.GetHistogram().GetSampleCount(); actual != expected {
+		failWithDiff(t, family, fmt.Sprintf("histogram %s: expected %d observations but was %d", family.GetName(), expected, actual), func() {
+			series.Histogram.SampleCount = &expected
+		})
+		return false
+	}
+	return true
+}
+
+// AssertSummaryObservations checks the number of values the summary, or timer, has observed
+func AssertSummaryObservations(t TestingT, metrics api.PrometheusMetrics, name string, expected uint64, labelValues ...string) bool {
+	t.Helper()
+	family, series, ok := findSeries(t, metrics, name, clientmodel.MetricType_SUMMARY, labelValues)
+	if !ok {
+		return false
+	}
+
+	if actual := series.GetSummary().GetSampleCount(); actual != expected {
+		failWithDiff(t, family, fmt.Sprintf("summary %s: expected %d observations but was %d", family.GetName(), expected, actual), func() {
+			series.Summary.SampleCount = &expected
+		})
+		return false
+	}
+	return true
+}
+
+func AssertNoMetric(t TestingT, metrics api.PrometheusMetrics, name string) bool {
+	t.Helper()
+	helper := metrics.TestHelper()
+	fullName := helper.MetricName(name)
+
+	gathered, err := helper.Gather()
+	if err != nil {
+		t.Errorf("could not gather metrics: %s", err)
+		return false
+	}
+
+	for _, each := range gathered {
+		if each.GetName() == fullName {
+			t.Errorf("expected no metric %s\n\n%s", fullName, diff("", exposition(each)))
+			return false
+		}
+	}
+	return true
+}
+
+// AssertMetricNames checks the full names, i.e. as exposed, of all registered metrics, in any order
+func AssertMetricNames(t TestingT, metrics api.PrometheusMetrics, expected ...string) bool {
+	t.Helper()
+	actual := metrics.TestHelper().MetricNames()
+
+	expectedText := sortedLines(expected)
+	actualText := sortedLines(actual)
+	if expectedText != actualText {
+		t.Errorf("metric names differ\n\n%s", diff(expectedText, actualText))
+		return false
+	}
+	return true
+}
+
+func findSeries(t TestingT, metrics api.PrometheusMetrics, name string, metricType clientmodel.MetricType, labelValues []string) (*clientmodel.MetricFamily, *clientmodel.Metric, bool) {
+	t.Helper()
+	family, series, err := metrics.TestHelper().Series(name, labelValues...)
+	if err != nil {
+		if family != nil {
+			t.Errorf("%s\n\n%s", err, exposition(family))
+		} else {
+			t.Errorf("%s", err)
+		}
+		return nil, nil, false
+	}
+
+	if family.GetType() != metricType {
+		t.Errorf("metric %s is a %s, not a %s\n\n%s", family.GetName(), family.GetType(), metricType, exposition(family))
+		return nil, nil, false
+	}
+	return family, series, true
+}
+
+// failWithDiff reports the difference between the gathered family and the same family once toExpected has
+// changed the series in question to its expected value
+func failWithDiff(t TestingT, family *clientmodel.MetricFamily, message string, toExpected func()) {
+	t.Helper()
+	actualText := exposition(family)
+	toExpected()
+	t.Errorf("%s\n\n%s", message, diff(exposition(family), actualText))
+}
+
+func exposition(family *clientmodel.MetricFamily) string {
+	var buf bytes.Buffer
+	if _, err := expfmt.MetricFamilyToText(&buf, family); err != nil {
+		return err.Error()
+	}
+	return buf.String()
+}
+
+func sortedLines(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, "\n")
+}
+
+// diff returns a line-by-line diff between two texts, based on their longest common subsequence
+func diff(expected string, actual string) string {
+	a := splitLines(expected)
+	b := splitLines(actual)
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString("--- expected\n+++ actual\n")
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out.WriteString(" " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out.WriteString("-" + a[i] + "\n")
+			i++
+		default:
+			out.WriteString("+" + b[j] + "\n")
+			j++
+		}
+	}
+	return out.String()
+}
+
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
@@ -0,0 +1,111 @@
+package promtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/poblish/promenade/api"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeT struct {
+	errors []string
+}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) Helper() {}
+
+func newTestMetrics() api.PrometheusMetrics {
+	metrics := api.NewMetrics(api.MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc"})
+	return &metrics
+}
+
+func TestPassingAssertions(t *testing.T) {
+	metrics := newTestMetrics()
+
+	metrics.CounterWithLabel("requests", "method").IncLabel("GET")
+	metrics.Gauge("queue").SetValue(5)
+	metrics.Histogram("size", []float64{1, 10}).Update(3)
+	metrics.Summary("latency").Observe(0.1)
+
+	AssertCounter(t, metrics, "requests", 1, "GET")
+	AssertCounterDelta(t, metrics, "requests", 2, func() {
+		metrics.CounterWithLabel("requests", "method").IncLabelBy("GET").Value(2)
+	}, "GET")
+	AssertCounterDelta(t, metrics, "requests", 1, func() {
+		metrics.CounterWithLabel("requests", "method").IncLabel("POST")
+	}, "POST")
+	AssertGauge(t, metrics, "queue", 5)
+	AssertGaugeBetween(t, metrics, "queue", 5, 10)
+	AssertHistogramObservations(t, metrics, "size", 1)
+	AssertSummaryObservations(t, metrics, "latency", 1)
+	AssertNoMetric(t, metrics, "unknown")
+	AssertMetricNames(t, metrics, "svc_queue", "svc_latency", "svc_requests", "svc_size")
+}
+
+func TestCounterDiff(t *testing.T) {
+	metrics := newTestMetrics()
+	metrics.CounterWithLabel("requests", "method").IncLabel("GET")
+	metrics.CounterWithLabel("requests", "method").IncLabel("POST")
+
+	fake := &fakeT{}
+	assert.False(t, AssertCounter(fake, metrics, "requests", 3, "GET"))
+	assert.Equal(t, []string{`counter svc_requests: expected 3 but was 1
+
+--- expected
++++ actual
+ # HELP svc_requests svc_requests
+ # TYPE svc_requests counter
+-svc_requests{method="GET"} 3
++svc_requests{method="GET"} 1
+ svc_requests{method="POST"} 1
+`}, fake.errors)
+}
+
+func TestCounterDeltaDiff(t *testing.T) {
+	metrics := newTestMetrics()
+	metrics.Counter("requests").IncBy(4)
+
+	fake := &fakeT{}
+	assert.False(t, AssertCounterDelta(fake, metrics, "requests", 2, func() { metrics.Counter("requests").Inc() }))
+	assert.Equal(t, []string{`counter svc_requests: expected to change by 2 but changed by 1
+
+--- expected
++++ actual
+ # HELP svc_requests svc_requests
+ # TYPE svc_requests counter
+-svc_requests 6
++svc_requests 5
+`}, fake.errors)
+}
+
+func TestFailures(t *testing.T) {
+	metrics := newTestMetrics()
+	metrics.CounterWithLabel("requests", "method").IncLabel("GET")
+	metrics.Gauge("queue").SetValue(12)
+	metrics.Histogram("size", []float64{1}).Update(3)
+
+	fake := &fakeT{}
+	assert.False(t, AssertCounter(fake, metrics, "missing", 1))
+	assert.False(t, AssertCounter(fake, metrics, "requests", 1, "PUT"))
+	assert.False(t, AssertCounter(fake, metrics, "requests", 1))
+	assert.False(t, AssertCounter(fake, metrics, "queue", 12))
+	assert.False(t, AssertGaugeBetween(fake, metrics, "queue", 0, 10))
+	assert.False(t, AssertHistogramObservations(fake, metrics, "size", 2))
+	assert.False(t, AssertNoMetric(fake, metrics, "queue"))
+	assert.False(t, AssertMetricNames(fake, metrics, "svc_queue", "svc_requests", "svc_other"))
+
+	assert.Equal(t, 8, len(fake.errors))
+	assert.Equal(t, "metric svc_missing not found", fake.errors[0])
+	assert.Contains(t, fake.errors[1], `metric svc_requests has no series with labels {method="PUT"}`)
+	assert.Contains(t, fake.errors[2], "metric svc_requests has labels [method], but 0 label values were given")
+	assert.Contains(t, fake.errors[3], "metric svc_queue is a GAUGE, not a COUNTER")
+	assert.Contains(t, fake.errors[4], "gauge svc_queue: expected between 0 and 10 but was 12")
+	assert.Contains(t, fake.errors[5], "-svc_size_count 2\n+svc_size_count 1\n")
+	assert.Equal(t, "expected no metric svc_queue\n\n--- expected\n+++ actual\n+# HELP svc_queue svc_queue\n+# TYPE svc_queue gauge\n+svc_queue 12\n", fake.errors[6])
+	assert.Equal(t, "metric names differ\n\n--- expected\n+++ actual\n-svc_other\n svc_queue\n svc_requests\n+svc_size\n", fake.errors[7])
+}
@@ -0,0 +1,242 @@
+// Package rules generates Prometheus recording and alerting rules for promenade metrics, from a catalogue or the
+// metrics registered at runtime
+package rules
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/poblish/promenade/api"
+	"go.yaml.in/yaml/v3"
+)
+
+type Opts struct {
+	GroupName   string              // default is "promenade"
+	Window      string              // for rates, default is "5m"
+	Quantiles   []float64           // recorded for histograms, default is 0.5, 0.9 and 0.99
+	ErrorRate   float64             // errors per second, per error type, to alert on, default is 0.1
+	LatencySLO  float64             // alert when the SLOQuantile of a histogram or timer exceeds this, default is no alert
+	SLOQuantile float64             // default is 0.99
+	For         string              // how long alert conditions must hold, default is "10m"
+	Severity    string              // default is "warning"
+	AggregateBy []string            // labels kept by every sum and max, besides the metric's own, default is "job"
+	Overrides   map[string]Override // by metric name, as exposed
+}
+
+// Override changes the rules for one metric. Zero values leave the defaults from Opts.
+type Override struct {
+	Disabled    bool              `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+	ErrorRate   float64           `yaml:"errorRate,omitempty" json:"errorRate,omitempty"` // also alerts on counters other than errors
+	LatencySLO  float64           `yaml:"latencySLO,omitempty" json:"latencySLO,omitempty"`
+	SLOQuantile float64           `yaml:"sloQuantile,omitempty" json:"sloQuantile,omitempty"`
+	For         string            `yaml:"for,omitempty" json:"for,omitempty"`
+	Severity    string            `yaml:"severity,omitempty" json:"severity,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"` // added to alerts
+}
+
+type RuleFile struct {
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+type RuleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+type Rule struct {
+	Record      string            `yaml:"record,omitempty"`
+	Alert       string            `yaml:"alert,omitempty"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+var defaultQuantiles = []float64{0.5, 0.9, 0.99}
+
+// NewRules returns recording rules for the per-second rate of every counter and the quantiles of every
+// histogram, with alerts on the error rate of the shared errors counter, and on latency for histograms and
+// timers when a LatencySLO is given. Other counters and summaries only alert when given an Override. Metric names
+// in the catalogue must be as exposed, e.g. from PrometheusMetricsImpl.Catalogue().
+func NewRules(catalogue *api.Catalogue, opts Opts) RuleFile {
+	if opts.GroupName == "" {
+		opts.GroupName = "promenade"
+	}
+	if opts.Window == "" {
+		opts.Window = "5m"
+	}
+	if len(opts.Quantiles) == 0 {
+		opts.Quantiles = defaultQuantiles
+	}
+	if opts.ErrorRate == 0 {
+		opts.ErrorRate = 0.1
+	}
+	if opts.SLOQuantile == 0 {
+		opts.SLOQuantile = 0.99
+	}
+	if opts.For == "" {
+		opts.For = "10m"
+	}
+	if opts.Severity == "" {
+		opts.Severity = "warning"
+	}
+	if len(opts.AggregateBy) == 0 {
+		opts.AggregateBy = []string{"job"}
+	}
+
+	group := RuleGroup{Name: opts.GroupName, Rules: []Rule{}}
+	for _, metric := range catalogue.Metrics {
+		override := opts.Overrides[metric.Name]
+		if override.Disabled {
+			continue
+		}
+		group.Rules = append(group.Rules, metricRules(metric, opts, override)...)
+	}
+	return RuleFile{Groups: []RuleGroup{group}}
+}
+
+// NewRulesForMetrics returns rules for every metric created so far, e.g. once a service has started
+func NewRulesForMetrics(metrics *api.PrometheusMetricsImpl, opts Opts) RuleFile {
+	return NewRules(metrics.Catalogue(), opts)
+}
+
+// YAML returns the rules as Prometheus expects to load them
+func (f RuleFile) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(f); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func metricRules(metric api.MetricDefinition, opts Opts, override Override) []Rule {
+	by := strings.Join(slices.Concat(opts.AggregateBy, metric.Labels), ", ")
+	level := strings.Join(opts.AggregateBy, "_") // the recorded name's prefix, by the Prometheus convention
+	var rules []Rule
+
+	switch metric.Type {
+	case api.CatalogueCounter:
+		rate := fmt.Sprintf("sum by (%s) (rate(%s[%s]))", by, metric.Name, opts.Window)
+		rules = append(rules, Rule{Record: fmt.Sprintf("%s:%s:rate%s", level, metric.Name, opts.Window), Expr: rate})
+
+		isErrors := len(metric.Labels) == 1 && metric.Labels[0] == "error_type"
+		if isErrors || override.ErrorRate > 0 {
+			threshold := orDefault(override.ErrorRate, opts.ErrorRate)
+			rules = append(rules, alert(metric, opts, override, "ErrorRateHigh",
+				fmt.Sprintf("%s > %s", rate, formatFloat(threshold)),
+				fmt.Sprintf("%s is over %s per second%s", metric.Name, formatFloat(threshold), labelSummary(metric.Labels))))
+		}
+
+	case api.CatalogueHistogram:
+		byBuckets := strings.Join(slices.Concat(opts.AggregateBy, []string{"le"}, metric.Labels), ", ")
+		for _, quantile := range opts.Quantiles {
+			rules = append(rules, Rule{Record: fmt.Sprintf("%s:%s:p%s_%s", level, metric.Name, percentile(quantile), opts.Window),
+				Expr: fmt.Sprintf("histogram_quantile(%s, sum by (%s) (rate(%s_bucket[%s])))", formatFloat(quantile), byBuckets, metric.Name, opts.Window)})
+		}
+
+		slo := override.LatencySLO
+		if metric.Unit == "" || metric.Unit == "seconds" {
+			slo = orDefault(slo, opts.LatencySLO) // not e.g. sizes in bytes
+		}
+		if slo > 0 {
+			quantile := orDefault(override.SLOQuantile, opts.SLOQuantile)
+			expr := fmt.Sprintf("histogram_quantile(%s, sum by (%s) (rate(%s_bucket[%s]))) > %s", formatFloat(quantile), byBuckets, metric.Name, opts.Window, formatFloat(slo))
+			rules = append(rules, latencyAlert(metric, opts, override, expr, quantile, slo))
+		}
+
+	case api.CatalogueSummary, api.CatalogueTimer:
+		slo := override.LatencySLO
+		if metric.Type == api.CatalogueTimer && (metric.Unit == "" || metric.Unit == "seconds") {
+			slo = orDefault(slo, opts.LatencySLO) // other summaries may not be latencies, so need an override
+		}
+		if slo > 0 {
+			quantile := orDefault(override.SLOQuantile, opts.SLOQuantile)
+			expr := fmt.Sprintf("max by (%s) (%s{quantile=\"%s\"}) > %s", by, metric.Name, formatFloat(quantile), formatFloat(slo))
+			rules = append(rules, latencyAlert(metric, opts, override, expr, quantile, slo))
+		}
+	}
+	return rules
+}
+
+func latencyAlert(metric api.MetricDefinition, opts Opts, override Override, expr string, quantile float64, slo float64) Rule {
+	return alert(metric, opts, override, "LatencySLOBreached", expr,
+		fmt.Sprintf("p%s of %s is over %ss%s", percentile(quantile), metric.Name, formatFloat(slo), labelSummary(metric.Labels)))
+}
+
+func alert(metric api.MetricDefinition, opts Opts, override Override, suffix string, expr string, summary string) Rule {
+	labels := map[string]string{"severity": orDefault(override.Severity, opts.Severity)}
+	if metric.Owner != "" {
+		labels["owner"] = metric.Owner
+	}
+	for name, value := range override.Labels {
+		labels[name] = value
+	}
+
+	annotations := map[string]string{"summary": summary}
+	if metric.Help != "" && metric.Help != metric.Name {
+		annotations["description"] = metric.Help
+	}
+
+	return Rule{Alert: alertName(metric.Name) + suffix, Expr: expr, For: orDefault(override.For, opts.For), Labels: labels, Annotations: annotations}
+}
+
+// alertName converts e.g. svc_http_errors to SvcHttpErrors, replacing anything else not allowed in a legacy
+// name, such as UTF-8 runes, with _
+func alertName(name string) string {
+	var buf strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == ':' }) {
+		first, size := utf8.DecodeRuneInString(part)
+		buf.WriteRune(unicode.ToUpper(first))
+		buf.WriteString(part[size:])
+	}
+
+	alert := strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, buf.String())
+	if alert != "" && alert[0] >= '0' && alert[0] <= '9' {
+		return "_" + alert
+	}
+	return alert
+}
+
+func labelSummary(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	values := make([]string, len(labels))
+	for i, label := range labels {
+		values[i] = label + "={{ $labels." + label + " }}"
+	}
+	return " for " + strings.Join(values, ", ")
+}
+
+func orDefault[T comparable](value T, defaultValue T) T {
+	var zero T
+	if value == zero {
+		return defaultValue
+	}
+	return value
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// percentile formats e.g. 0.99 as 99, and 0.999 as 99_9 for use in rule names
+func percentile(quantile float64) string {
+	return strings.ReplaceAll(formatFloat(math.Round(quantile*1e6)/1e4), ".", "_")
+}
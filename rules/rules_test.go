@@ -0,0 +1,104 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/poblish/promenade/api"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRulesForMetrics(t *testing.T) {
+	metrics := api.NewMetrics(api.MetricOpts{Registry: prometheus.NewRegistry(), MetricNamePrefix: "svc"})
+	metrics.CounterWithLabel("http.requests", "method", "Requests served")
+	metrics.Gauge("http.connections")
+	metrics.HistogramForResponseTime("db_latency")
+	metrics.Summary("sizes")
+	metrics.Error("oops")
+
+	file := NewRulesForMetrics(&metrics, Opts{LatencySLO: 0.5})
+	assert.Len(t, file.Groups, 1)
+	assert.Equal(t, "promenade", file.Groups[0].Name)
+
+	assert.Equal(t, []Rule{
+		{Record: "job:svc_db_latency:p50_5m", Expr: "histogram_quantile(0.5, sum by (job, le) (rate(svc_db_latency_bucket[5m])))"},
+		{Record: "job:svc_db_latency:p90_5m", Expr: "histogram_quantile(0.9, sum by (job, le) (rate(svc_db_latency_bucket[5m])))"},
+		{Record: "job:svc_db_latency:p99_5m", Expr: "histogram_quantile(0.99, sum by (job, le) (rate(svc_db_latency_bucket[5m])))"},
+		{Alert: "SvcDbLatencyLatencySLOBreached", Expr: "histogram_quantile(0.99, sum by (job, le) (rate(svc_db_latency_bucket[5m]))) > 0.5", For: "10m",
+			Labels:      map[string]string{"severity": "warning"},
+			Annotations: map[string]string{"summary": "p99 of svc_db_latency is over 0.5s"}},
+		{Record: "job:svc_errors:rate5m", Expr: "sum by (job, error_type) (rate(svc_errors[5m]))"},
+		{Alert: "SvcErrorsErrorRateHigh", Expr: "sum by (job, error_type) (rate(svc_errors[5m])) > 0.1", For: "10m",
+			Labels:      map[string]string{"severity": "warning"},
+			Annotations: map[string]string{"summary": "svc_errors is over 0.1 per second for error_type={{ $labels.error_type }}"}},
+		{Record: "job:svc_http_requests:rate5m", Expr: "sum by (job, method) (rate(svc_http_requests[5m]))"},
+	}, file.Groups[0].Rules)
+}
+
+func TestOverrides(t *testing.T) {
+	catalogue := &api.Catalogue{Metrics: []api.MetricDefinition{
+		{Name: "svc_db_query", Type: api.CatalogueTimer, Labels: []string{"table"}, Help: "Query times", Owner: "data"},
+		{Name: "svc_errors", Type: api.CatalogueCounter, Labels: []string{"error_type"}},
+		{Name: "svc_failures", Type: api.CatalogueCounter},
+		{Name: "svc_latency", Type: api.CatalogueHistogram},
+		{Name: "svc_payload", Type: api.CatalogueHistogram, Unit: "bytes"},
+	}}
+
+	file := NewRules(catalogue, Opts{Window: "1m", LatencySLO: 3, Quantiles: []float64{0.999}, Overrides: map[string]Override{
+		"svc_db_query": {LatencySLO: 0.25, SLOQuantile: 0.95, Severity: "critical", Labels: map[string]string{"team": "data"}},
+		"svc_errors":   {Disabled: true},
+		"svc_failures": {ErrorRate: 2, For: "1m"},
+	}})
+
+	assert.Equal(t, []Rule{
+		{Alert: "SvcDbQueryLatencySLOBreached", Expr: `max by (job, table) (svc_db_query{quantile="0.95"}) > 0.25`, For: "10m",
+			Labels:      map[string]string{"severity": "critical", "team": "data", "owner": "data"},
+			Annotations: map[string]string{"summary": "p95 of svc_db_query is over 0.25s for table={{ $labels.table }}", "description": "Query times"}},
+		{Record: "job:svc_failures:rate1m", Expr: "sum by (job) (rate(svc_failures[1m]))"},
+		{Alert: "SvcFailuresErrorRateHigh", Expr: "sum by (job) (rate(svc_failures[1m])) > 2", For: "1m",
+			Labels:      map[string]string{"severity": "warning"},
+			Annotations: map[string]string{"summary": "svc_failures is over 2 per second"}},
+		{Record: "job:svc_latency:p99_9_1m", Expr: "histogram_quantile(0.999, sum by (job, le) (rate(svc_latency_bucket[1m])))"},
+		{Alert: "SvcLatencyLatencySLOBreached", Expr: "histogram_quantile(0.99, sum by (job, le) (rate(svc_latency_bucket[1m]))) > 3", For: "10m",
+			Labels:      map[string]string{"severity": "warning"},
+			Annotations: map[string]string{"summary": "p99 of svc_latency is over 3s"}},
+		{Record: "job:svc_payload:p99_9_1m", Expr: "histogram_quantile(0.999, sum by (job, le) (rate(svc_payload_bucket[1m])))"},
+	}, file.Groups[0].Rules)
+}
+
+func TestYAML(t *testing.T) {
+	catalogue := &api.Catalogue{Metrics: []api.MetricDefinition{{Name: "svc_requests", Type: api.CatalogueCounter}}}
+
+	data, err := NewRules(catalogue, Opts{GroupName: "svc"}).YAML()
+	assert.NoError(t, err)
+	assert.Equal(t, `groups:
+  - name: svc
+    rules:
+      - record: job:svc_requests:rate5m
+        expr: sum by (job) (rate(svc_requests[5m]))
+`, string(data))
+}
+
+func TestTimerUnitsAndAggregation(t *testing.T) {
+	catalogue := &api.Catalogue{Metrics: []api.MetricDefinition{
+		{Name: "svc_calc", Type: api.CatalogueTimer, Unit: "seconds"},
+		{Name: "svc_query_milliseconds", Type: api.CatalogueTimer, Unit: "milliseconds"},
+		{Name: "svc_requests", Type: api.CatalogueCounter, Labels: []string{"method"}},
+	}}
+
+	file := NewRules(catalogue, Opts{LatencySLO: 0.5, AggregateBy: []string{"cluster", "service"}})
+	assert.Equal(t, []Rule{
+		{Alert: "SvcCalcLatencySLOBreached", Expr: `max by (cluster, service) (svc_calc{quantile="0.99"}) > 0.5`, For: "10m",
+			Labels:      map[string]string{"severity": "warning"},
+			Annotations: map[string]string{"summary": "p99 of svc_calc is over 0.5s"}},
+		{Record: "cluster_service:svc_requests:rate5m", Expr: "sum by (cluster, service, method) (rate(svc_requests[5m]))"},
+	}, file.Groups[0].Rules)
+}
+
+func TestAlertName(t *testing.T) {
+	assert.Equal(t, "SvcHttpErrors", alertName("svc_http_errors"))
+	assert.Equal(t, "JobSvcRequestsRate5m", alertName("job:svc_requests:rate5m"))
+	assert.Equal(t, "_t_Requests", alertName("été_requests"))
+	assert.Equal(t, "_2xxResponses", alertName("2xx_responses"))
+	assert.Equal(t, "Http_requests", alertName("http.requests"))
+}
@@ -0,0 +1,348 @@
+package statsdbackend
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/poblish/promenade/api"
+)
+
+type Opts struct {
+	Network       string        // "udp" (default) or "unixgram"
+	Address       string        // host:port, or socket path for "unixgram"
+	MaxPacketSize int           // buffered lines are sent once a packet would exceed this, default 1432 bytes
+	FlushInterval time.Duration // how often partially-filled packets are sent, default 100ms
+	DogStatsD     bool          // send labels as DogStatsD tags; plain StatsD appends label values to the name
+}
+
+// Sink buffers StatsD lines and writes them in packets to a UDP or Unix datagram socket
+type Sink struct {
+	sync.Mutex
+	conn          net.Conn
+	buffer        []byte
+	maxPacketSize int
+	dogStatsD     bool
+	done          chan struct{}
+	flusher       sync.WaitGroup
+	closeOnce     sync.Once
+	closeErr      error
+}
+
+func NewSink(opts Opts) (*Sink, error) {
+	if opts.Network == "" {
+		opts.Network = "udp"
+	}
+	if opts.MaxPacketSize <= 0 {
+		opts.MaxPacketSize = 1432 // fits within an Ethernet MTU
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 100 * time.Millisecond
+	}
+
+	conn, err := net.Dial(opts.Network, opts.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	sink := &Sink{conn: conn,
+		buffer:        make([]byte, 0, opts.MaxPacketSize),
+		maxPacketSize: opts.MaxPacketSize,
+		dogStatsD:     opts.DogStatsD,
+		done:          make(chan struct{})}
+
+	sink.flusher.Add(1)
+	go sink.flushPeriodically(opts.FlushInterval)
+	return sink, nil
+}
+
+// New returns a Backend that writes StatsD lines to the sink, instead of registering Prometheus collectors.
+// Counters, gauges and timers map to the c, g and ms types, and histograms and summaries to h. Characters
+// StatsD reserves, e.g. the colons Prometheus allows in names, are replaced with _.
+func New(sink *Sink) api.Backend {
+	return statsdBackend{sink: sink}
+}
+
+func (s *Sink) flushPeriodically(interval time.Duration) {
+	defer s.flusher.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = s.Flush()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *Sink) Flush() error {
+	s.Lock()
+	defer s.Unlock()
+	return s.flushLocked()
+}
+
+func (s *Sink) flushLocked() error {
+	if len(s.buffer) == 0 {
+		return nil
+	}
+	_, err := s.conn.Write(s.buffer)
+	s.buffer = s.buffer[:0]
+	return err
+}
+
+// Close stops the periodic flush, sends anything still buffered, and closes the socket. Later calls return
+// the same error.
+func (s *Sink) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.flusher.Wait()
+
+		flushErr := s.Flush()
+		if s.closeErr = s.conn.Close(); s.closeErr == nil {
+			s.closeErr = flushErr
+		}
+	})
+	return s.closeErr
+}
+
+func (s *Sink) send(name string, value float64, statsdType string, tags string) {
+	line := make([]byte, 0, len(name)+len(tags)+24)
+	line = append(line, name...)
+	line = append(line, ':')
+	line = strconv.AppendFloat(line, value, 'f', -1, 64)
+	line = append(line, '|')
+	line = append(line, statsdType...)
+	line = append(line, tags...)
+
+	s.Lock()
+	defer s.Unlock()
+
+	if len(s.buffer) > 0 && len(s.buffer)+1+len(line) > s.maxPacketSize {
+		_ = s.flushLocked()
+	}
+	if len(s.buffer) > 0 {
+		s.buffer = append(s.buffer, '\n')
+	}
+	s.buffer = append(s.buffer, line...)
+}
+
+// labelled returns the name and tags to send for a set of label values
+func (s *Sink) labelled(name string, labelNames []string, labelValues []string) (string, string) {
+	if len(labelNames) != len(labelValues) {
+		panic("expected " + strconv.Itoa(len(labelNames)) + " label values but got " + strconv.Itoa(len(labelValues)))
+	}
+
+	if !s.dogStatsD {
+		sanitised := make([]string, len(labelValues))
+		for i, each := range labelValues {
+			sanitised[i] = sanitise(each, ".")
+		}
+		return name + "." + strings.Join(sanitised, "."), ""
+	}
+
+	var tags strings.Builder
+	tags.WriteString("|#")
+	for i, labelName := range labelNames {
+		if i > 0 {
+			tags.WriteByte(',')
+		}
+		tags.WriteString(labelName)
+		tags.WriteByte(':')
+		tags.WriteString(sanitise(labelValues[i], ""))
+	}
+	return name, tags.String()
+}
+
+// reserved separate the parts of a StatsD line, or lines in a packet, so can't appear in names, or label names
+// or values
+const reserved = "|,:#@\n\r"
+
+// sanitise replaces reserved characters, and any others given, with _
+func sanitise(value string, others string) string {
+	if !strings.ContainsAny(value, reserved+others) {
+		return value
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(reserved+others, r) {
+			return '_'
+		}
+		return r
+	}, value)
+}
+
+func sanitiseAll(values []string) []string {
+	sanitised := make([]string, len(values))
+	for i, each := range values {
+		sanitised[i] = sanitise(each, "")
+	}
+	return sanitised
+}
+
+type statsdBackend struct {
+	sink *Sink
+}
+
+func (b statsdBackend) NewCounter(opts api.InstrumentOpts) api.CounterInstrument {
+	return statsdCounter{sink: b.sink, name: sanitise(opts.Name, "")}
+}
+
+func (b statsdBackend) NewCounterVec(opts api.InstrumentOpts) api.CounterVecInstrument {
+	return statsdCounterVec{sink: b.sink, name: sanitise(opts.Name, ""), labelNames: sanitiseAll(opts.LabelNames)}
+}
+
+func (b statsdBackend) NewGauge(opts api.InstrumentOpts) api.GaugeInstrument {
+	return &statsdGauge{sink: b.sink, name: sanitise(opts.Name, "")}
+}
+
+func (b statsdBackend) NewGaugeVec(opts api.InstrumentOpts) api.GaugeVecInstrument {
+	return &statsdGaugeVec{sink: b.sink, name: sanitise(opts.Name, ""), labelNames: sanitiseAll(opts.LabelNames), children: make(map[string]*statsdGauge)}
+}
+
+func (b statsdBackend) NewHistogram(opts api.InstrumentOpts) api.ObserverInstrument {
+	return statsdObserver{sink: b.sink, name: sanitise(opts.Name, ""), statsdType: "h", scale: 1}
+}
+
+func (b statsdBackend) NewSummary(opts api.InstrumentOpts) api.ObserverInstrument {
+	return statsdObserver{sink: b.sink, name: sanitise(opts.Name, ""), statsdType: "h", scale: 1}
+}
+
+func (b statsdBackend) NewSummaryVec(opts api.InstrumentOpts) api.ObserverVecInstrument {
+	return statsdObserverVec{sink: b.sink, name: sanitise(opts.Name, ""), labelNames: sanitiseAll(opts.LabelNames), statsdType: "h", scale: 1}
+}
+
+func (b statsdBackend) NewTimer(opts api.InstrumentOpts) api.ObserverInstrument {
+	return statsdObserver{sink: b.sink, name: sanitise(opts.Name, ""), statsdType: "ms", scale: toMilliseconds(opts.Unit)}
+}
+
+func (b statsdBackend) NewTimerVec(opts api.InstrumentOpts) api.ObserverVecInstrument {
+	return statsdObserverVec{sink: b.sink, name: sanitise(opts.Name, ""), labelNames: sanitiseAll(opts.LabelNames), statsdType: "ms", scale: toMilliseconds(opts.Unit)}
+}
+
+// toMilliseconds scales what timers observe, in seconds unless given another unit, to the milliseconds of StatsD
+// timings
+func toMilliseconds(unit string) float64 {
+	if unit == api.Milliseconds.String() {
+		return 1
+	}
+	return 1000
+}
+
+type statsdCounter struct {
+	sink *Sink
+	name string
+	tags string
+}
+
+func (c statsdCounter) Inc() {
+	c.Add(1)
+}
+
+func (c statsdCounter) Add(inc float64) {
+	c.sink.send(c.name, inc, "c", c.tags)
+}
+
+type statsdCounterVec struct {
+	sink       *Sink
+	name       string
+	labelNames []string
+}
+
+func (v statsdCounterVec) WithLabelValues(labelValues ...string) api.CounterInstrument {
+	name, tags := v.sink.labelled(v.name, v.labelNames, labelValues)
+	return statsdCounter{sink: v.sink, name: name, tags: tags}
+}
+
+// statsdGauge keeps the current value itself, and always sends it in full: DogStatsD has no relative
+// gauge updates, and plain StatsD would read a negative value as a decrement.
+type statsdGauge struct {
+	sync.Mutex
+	sink  *Sink
+	name  string
+	tags  string
+	value float64
+}
+
+func (g *statsdGauge) Set(value float64) {
+	g.Lock()
+	defer g.Unlock()
+	g.value = value
+	g.sendLocked()
+}
+
+func (g *statsdGauge) Add(inc float64) {
+	g.Lock()
+	defer g.Unlock()
+	g.value += inc
+	g.sendLocked()
+}
+
+func (g *statsdGauge) Sub(dec float64) {
+	g.Add(-dec)
+}
+
+func (g *statsdGauge) Inc() {
+	g.Add(1)
+}
+
+func (g *statsdGauge) Dec() {
+	g.Add(-1)
+}
+
+func (g *statsdGauge) sendLocked() {
+	if g.value < 0 && !g.sink.dogStatsD {
+		g.sink.send(g.name, 0, "g", g.tags)
+	}
+	g.sink.send(g.name, g.value, "g", g.tags)
+}
+
+type statsdGaugeVec struct {
+	sync.Mutex
+	sink       *Sink
+	name       string
+	labelNames []string
+	children   map[string]*statsdGauge
+}
+
+func (v *statsdGaugeVec) WithLabelValues(labelValues ...string) api.GaugeInstrument {
+	name, tags := v.sink.labelled(v.name, v.labelNames, labelValues)
+
+	v.Lock()
+	defer v.Unlock()
+	child, ok := v.children[name+tags]
+	if !ok {
+		child = &statsdGauge{sink: v.sink, name: name, tags: tags}
+		v.children[name+tags] = child
+	}
+	return child
+}
+
+type statsdObserver struct {
+	sink       *Sink
+	name       string
+	tags       string
+	statsdType string
+	scale      float64
+}
+
+func (o statsdObserver) Observe(value float64) {
+	o.sink.send(o.name, value*o.scale, o.statsdType, o.tags)
+}
+
+type statsdObserverVec struct {
+	sink       *Sink
+	name       string
+	labelNames []string
+	statsdType string
+	scale      float64
+}
+
+func (v statsdObserverVec) WithLabelValues(labelValues ...string) api.ObserverInstrument {
+	name, tags := v.sink.labelled(v.name, v.labelNames, labelValues)
+	return statsdObserver{sink: v.sink, name: name, tags: tags, statsdType: v.statsdType, scale: v.scale}
+}
@@ -0,0 +1,160 @@
+package statsdbackend
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/poblish/promenade/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsdDogStatsD(t *testing.T) {
+	listener, sink := newStatsdTestSink(t, Opts{DogStatsD: true})
+	defer sink.Close()
+	metrics := api.NewMetrics(api.MetricOpts{MetricNamePrefix: "z", Backend: New(sink)})
+
+	metrics.Counter("Mine").Inc()
+	metrics.Counter("Mine").IncBy(7)
+	metrics.CounterWithLabels("animals", []string{"animal", "breed"}).IncLabel("cat", "persian")
+	metrics.Gauge("g").SetValue(5)
+	metrics.Gauge("g").DecBy(10)
+	metrics.GaugeWithLabel("current animals", "animal").IncLabels("dog")
+	metrics.Histogram("h", []float64{1, 10}).Update(2.5)
+	metrics.SummaryWithLabel("s", "animal").Observe(1.5, "cat")
+	metrics.Error("bad")
+
+	assert.Nil(t, sink.Flush())
+	assert.Equal(t, []string{
+		"z_mine:1|c",
+		"z_mine:7|c",
+		"z_animals:1|c|#animal:cat,breed:persian",
+		"z_g:5|g",
+		"z_g:-5|g",
+		"z_current_animals:1|g|#animal:dog",
+		"z_h:2.5|h",
+		"z_s:1.5|h|#animal:cat",
+		"z_errors:1|c|#error_type:bad",
+	}, receiveStatsdLines(t, listener))
+
+	metrics.Timer("timer").Stop()
+	metrics.TimerWithLabel("animal timer", "animal", "cat").Stop()
+
+	assert.Nil(t, sink.Flush())
+	timings := receiveStatsdLines(t, listener)
+	assert.Regexp(t, `^z_timer:[0-9.]+\|ms$`, timings[0])
+	assert.Regexp(t, `^z_animal_timer:[0-9.]+\|ms\|#animal:cat$`, timings[1])
+}
+
+func TestStatsdPlain(t *testing.T) {
+	listener, sink := newStatsdTestSink(t, Opts{})
+	defer sink.Close()
+	metrics := api.NewMetrics(api.MetricOpts{MetricNamePrefix: "z", Backend: New(sink)})
+
+	metrics.CounterWithLabels("animals", []string{"animal", "breed"}).IncLabelBy("cat", "persian").Value(3)
+	metrics.Gauge("g").SetValue(-2)
+
+	assert.Nil(t, sink.Flush())
+	assert.Equal(t, []string{"z_animals.cat.persian:3|c", "z_g:0|g", "z_g:-2|g"}, receiveStatsdLines(t, listener))
+	assert.Panics(t, func() { metrics.CounterWithLabels("animals", []string{"animal", "breed"}).IncLabel("cat") })
+}
+
+func TestStatsdSanitisesLabelValues(t *testing.T) {
+	listener, sink := newStatsdTestSink(t, Opts{DogStatsD: true})
+	defer sink.Close()
+	metrics := api.NewMetrics(api.MetricOpts{Backend: New(sink)})
+
+	metrics.CounterWithLabel("c", "tenant").IncLabel("a,admin:true|#x\nevil:1|c")
+
+	assert.Nil(t, sink.Flush())
+	assert.Equal(t, []string{"c:1|c|#tenant:a_admin_true__x_evil_1_c"}, receiveStatsdLines(t, listener))
+
+	plainListener, plainSink := newStatsdTestSink(t, Opts{})
+	defer plainSink.Close()
+	plain := api.NewMetrics(api.MetricOpts{Backend: New(plainSink)})
+
+	plain.CounterWithLabel("c", "host").IncLabel("db1.example.com:5432")
+
+	assert.Nil(t, plainSink.Flush())
+	assert.Equal(t, []string{"c.db1_example_com_5432:1|c"}, receiveStatsdLines(t, plainListener))
+}
+
+func TestStatsdSanitisesNames(t *testing.T) {
+	listener, sink := newStatsdTestSink(t, Opts{DogStatsD: true})
+	defer sink.Close()
+	metrics := api.NewMetrics(api.MetricOpts{Backend: New(sink), MetricNamePrefix: "v:", NameValidation: api.UTF8Names})
+
+	metrics.CounterWithLabel("animals", "ty|pe").IncLabel("cat")
+	metrics.Gauge("a#b").SetValue(2)
+
+	assert.Nil(t, sink.Flush())
+	assert.Equal(t, []string{"v__animals:1|c|#ty_pe:cat", "v__a_b:2|g"}, receiveStatsdLines(t, listener))
+
+	plainListener, plainSink := newStatsdTestSink(t, Opts{})
+	defer plainSink.Close()
+	plain := api.NewMetrics(api.MetricOpts{Backend: New(plainSink), MetricNamePrefix: "v:"})
+
+	plain.TimerWithLabel("calc", "kind", "x").ObserveDuration(time.Second)
+
+	assert.Nil(t, plainSink.Flush())
+	assert.Equal(t, []string{"v__calc.x:1000|ms"}, receiveStatsdLines(t, plainListener))
+}
+
+func TestStatsdTimerUnits(t *testing.T) {
+	listener, sink := newStatsdTestSink(t, Opts{})
+	defer sink.Close()
+	metrics := api.NewMetrics(api.MetricOpts{Backend: New(sink)})
+
+	metrics.Timer("plain").ObserveDuration(250 * time.Millisecond)
+	metrics.WithUnit(api.Seconds).Timer("q").ObserveDuration(250 * time.Millisecond)
+	metrics.WithUnit(api.Milliseconds).Timer("q").ObserveDuration(250 * time.Millisecond)
+	metrics.WithUnit(api.Milliseconds).TimerWithLabel("call", "api", "users").ObserveDuration(250 * time.Millisecond)
+
+	assert.Nil(t, sink.Flush())
+	assert.Equal(t, []string{"plain:250|ms", "q_seconds:250|ms", "q_milliseconds:250|ms", "call_milliseconds.users:250|ms"},
+		receiveStatsdLines(t, listener))
+}
+
+func TestStatsdCloseTwice(t *testing.T) {
+	_, sink := newStatsdTestSink(t, Opts{})
+	assert.Nil(t, sink.Close())
+	assert.Nil(t, sink.Close())
+}
+
+func TestStatsdPacketSize(t *testing.T) {
+	listener, sink := newStatsdTestSink(t, Opts{MaxPacketSize: 21})
+	metrics := api.NewMetrics(api.MetricOpts{Backend: New(sink)})
+
+	metrics.Counter("abcdef").Inc()
+	metrics.Counter("ghijkl").Inc()
+	metrics.Counter("mnopqr").Inc()
+
+	// Third line would exceed the packet size, so the first two are sent without waiting for a flush
+	assert.Equal(t, []string{"abcdef:1|c", "ghijkl:1|c"}, receiveStatsdLines(t, listener))
+
+	assert.Nil(t, sink.Close())
+	assert.Equal(t, []string{"mnopqr:1|c"}, receiveStatsdLines(t, listener))
+}
+
+func newStatsdTestSink(t *testing.T, opts Opts) (net.PacketConn, *Sink) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	opts.Address = listener.LocalAddr().String()
+	opts.FlushInterval = time.Hour
+
+	sink, err := NewSink(opts)
+	assert.Nil(t, err)
+	return listener, sink
+}
+
+func receiveStatsdLines(t *testing.T, listener net.PacketConn) []string {
+	assert.Nil(t, listener.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	packet := make([]byte, 65536)
+	n, _, err := listener.ReadFrom(packet)
+	assert.Nil(t, err)
+	return strings.Split(string(packet[:n]), "\n")
+}